@@ -1,58 +1,255 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"1337b04rd/internal/adapters/left/transport"
 	"1337b04rd/internal/adapters/right/api"
+	"1337b04rd/internal/adapters/right/avatars"
 	"1337b04rd/internal/adapters/right/db"
+	"1337b04rd/internal/adapters/right/localfs"
 	"1337b04rd/internal/adapters/right/minio"
+	"1337b04rd/internal/adapters/right/ratelimit"
 	"1337b04rd/internal/application"
+	"1337b04rd/internal/config"
+	"1337b04rd/internal/domain"
+	"1337b04rd/internal/ports/right"
+	"1337b04rd/pkg/imaging"
 	"1337b04rd/pkg/logger"
 )
 
 func main() {
+	// Конфигурация
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			if err := runMigrate(cfg, os.Args[2:], os.Stdout); err != nil {
+				log.Fatalf("Migration failed: %v", err)
+			}
+			return
+		case "seed-avatars":
+			if err := runSeedAvatars(cfg, os.Args[2:], os.Stdout); err != nil {
+				log.Fatalf("Seeding avatars failed: %v", err)
+			}
+			return
+		}
+	}
+
 	// Логгер
-	logger, err := logger.NewCustomLogger()
+	logger, err := logger.NewCustomLogger(cfg.Log.Dir)
 	if err != nil {
 		log.Fatalf("Failed to initialize logger: %v", err)
 	}
 	logger.Info("Logger initialized successfully")
 
-	postgres := db.NewPostgres()
+	postgres, err := db.NewPostgres(cfg.DB)
+	if err != nil {
+		logger.Error("Failed to connect to the database:", err)
+		log.Fatalf("Database initialization error: %v", err)
+	}
 	defer postgres.Close()
 	logger.Info("Database connection established successfully")
 
-	// Инициализация MinIO
-	minioClient, err := minio.NewImageStorage(
-		"minio:9000",
-		"minioadmin",
-		"minioadmin",
-		"images",
-		false,
-	)
+	// Хранилище изображений
+	imageStorage, storagePing, err := newImageStorage(cfg)
 	if err != nil {
-		logger.Error("Failed to initialize MinIO:", err)
-		log.Fatalf("MinIO initialization error: %v", err)
+		logger.Error("Failed to initialize image storage:", err)
+		log.Fatalf("Image storage initialization error: %v", err)
 	}
-	logger.Info("MinIO client initialized successfully")
+	logger.Info("Image storage initialized successfully:", cfg.Storage.Backend)
 
-	// Инициализация Rick and Morty API
-	rickAndMortyAPI, err := api.NewRickAndMortyAPI()
-	if err != nil {
-		logger.Error("Failed to initialize Rick and Morty API:", err)
-		log.Fatalf("Rick and Morty API initialization error: %v", err)
+	avatarProvider, avatarsPing := newAvatarProvider(cfg, postgres, imageStorage, storagePing)
+	logger.Info("Avatar provider initialized successfully:", cfg.Avatar.Provider)
+	user_service := application.NewUser(application.UsernamePolicy{
+		MinLength: cfg.Username.MinLength,
+		MaxLength: cfg.Username.MaxLength,
+		Blocklist: cfg.Username.Blocklist,
+	})
+
+	lifetime := application.LifetimePolicy{
+		TTL:              cfg.Thread.TTL,
+		CommentTTL:       cfg.Thread.CommentTTL,
+		MaxLifetime:      cfg.Thread.MaxLifetime,
+		BumpLimit:        cfg.Thread.BumpLimit,
+		ArchiveRetention: cfg.Thread.ArchiveRetention,
 	}
-	logger.Info("Rick and Morty API initialized successfully")
-	user_service := application.NewUser()
 
-	service := application.NewApp(postgres, rickAndMortyAPI, minioClient, *user_service)
+	images := application.ImagePolicy{
+		Limits: imaging.Limits{
+			MaxWidth:  cfg.Image.MaxWidth,
+			MaxHeight: cfg.Image.MaxHeight,
+			MaxPixels: cfg.Image.MaxPixels,
+			MaxFrames: cfg.Image.MaxFrames,
+		},
+		ThumbnailSizes: cfg.Image.ThumbnailSizes,
+		GCGrace:        cfg.Image.GCGrace,
+	}
+
+	service := application.NewApp(postgres, avatarProvider, imageStorage, *user_service, lifetime, images, cfg.Session.TTL)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Readiness отвечает 503, пока не применены миграции и не запущен планировщик
+	health := transport.NewHealth()
+	health.AddCheck("postgres", postgres.Ping)
+	health.AddCheck("storage", storagePing)
+	health.AddCheck("avatars", avatarsPing)
+
+	if cfg.HTTP.CSRFSecret == "" {
+		logger.Warn("CSRF_SECRET is not set: forms will break after restart and across replicas")
+	}
+
+	if !cfg.Moderation.Enabled() {
+		logger.Info("MOD_PASSWORD is not set: moderation is disabled")
+	}
+
+	limiter := newRateLimiter(cfg, postgres)
 
-	logger.Info("Service initialized successfully")
 	// Запуск сервера
-	server := transport.NewHTTPServer(service, logger, minioClient)
-	if err := server.Serve(); err != nil {
-		logger.Error("Failed to start server:", err)
-		log.Fatalf("Server error: %v", err)
+	server := transport.NewHTTPServer(service, logger, imageStorage, health, limiter, cfg.HTTP, cfg.Moderation)
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.Serve()
+	}()
+
+	if cfg.Migrations.OnStart {
+		migrator, err := postgres.Migrator(cfg.Migrations.Dir)
+		if err != nil {
+			logger.Error("Failed to load migrations:", err)
+			log.Fatalf("Migrations error: %v", err)
+		}
+		applied, err := migrator.Up(ctx, false)
+		if err != nil {
+			logger.Error("Failed to apply migrations:", err)
+			log.Fatalf("Migrations error: %v", err)
+		}
+		logger.Info("Database schema is up to date, applied migrations:", len(applied))
+	}
+
+	if err := service.StartExpiryScheduler(ctx, cfg.Thread.SweepInterval); err != nil {
+		logger.Error("Failed to start expiry scheduler:", err)
+		log.Fatalf("Expiry scheduler error: %v", err)
+	}
+
+	if cfg.Image.GCInterval > 0 {
+		service.StartImageGC(ctx, cfg.Image.GCInterval)
+	}
+
+	limiter.StartPruning(ctx, cfg.RateLimit.PruneInterval)
+
+	if cfg.Session.CleanupInterval > 0 {
+		service.StartSessionCleanup(ctx, cfg.Session.CleanupInterval)
+	}
+
+	health.SetReady()
+	logger.Info("Service initialized successfully")
+
+	select {
+	case err := <-serverErr:
+		if err != nil {
+			logger.Error("Failed to start server:", err)
+			log.Fatalf("Server error: %v", err)
+		}
+	case <-ctx.Done():
+	}
+
+	// Плавная остановка: readiness сразу начинает отвечать 503, и в течение ShutdownGrace
+	// балансировщик успевает убрать реплику. Затем дожидаемся активных запросов
+	// (в том числе загрузок), фоновых задач и таймеров архивации и только потом
+	// закрываем пул БД.
+	logger.Info("Shutting down")
+	health.SetShuttingDown()
+	time.Sleep(cfg.HTTP.ShutdownGrace)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Error("Failed to drain HTTP requests:", err)
+	}
+	if err := service.WaitBackground(shutdownCtx); err != nil {
+		logger.Error("Failed to stop background jobs:", err)
+	}
+	if err := service.StopExpiryScheduler(shutdownCtx); err != nil {
+		logger.Error("Failed to stop expiry scheduler:", err)
+	}
+	logger.Info("Shutdown complete")
+}
+
+// newImageStorage создаёт хранилище изображений согласно STORAGE_BACKEND
+// и возвращает вместе с ним проверку для readiness
+func newImageStorage(cfg *config.Config) (right.ImageStorage, transport.HealthCheck, error) {
+	switch cfg.Storage.Backend {
+	case config.StorageFS:
+		storage, err := localfs.NewImageStorage(cfg.Storage.Dir)
+		if err != nil {
+			return nil, nil, err
+		}
+		return storage, storage.Ping, nil
+	default:
+		storage, err := minio.NewImageStorage(cfg.Minio)
+		if err != nil {
+			return nil, nil, err
+		}
+		return storage, storage.Ping, nil
+	}
+}
+
+// newAvatarProvider создаёт провайдера аватаров согласно AVATAR_PROVIDER вместе с проверкой
+// для readiness. Identicon зависит только от хранилища изображений, поэтому его проверка —
+// storagePing; с запасным Identicon провайдер готов, если работает хотя бы один из двух.
+func newAvatarProvider(cfg *config.Config, postgres *db.Postgres, storage right.ImageStorage, storagePing transport.HealthCheck) (right.AvatarProvider, transport.HealthCheck) {
+	identicon := avatars.NewIdenticon(storage)
+	if cfg.Avatar.Provider == config.AvatarIdenticon {
+		return identicon, storagePing
+	}
+
+	// Rick and Morty API с кэшем в БД, который работает и без доступа к API
+	cache := avatars.NewCache(api.NewRickAndMortyAPI(), postgres, storage)
+	if cfg.Avatar.Fallback {
+		return avatars.NewFallback(cache, identicon), anyHealthy(cache.Ping, storagePing)
+	}
+	return cache, cache.Ping
+}
+
+// anyHealthy проходит, если прошла хотя бы одна из проверок
+func anyHealthy(checks ...transport.HealthCheck) transport.HealthCheck {
+	return func(ctx context.Context) error {
+		var errs []error
+		for _, check := range checks {
+			err := check(ctx)
+			if err == nil {
+				return nil
+			}
+			errs = append(errs, err)
+		}
+		return errors.Join(errs...)
+	}
+}
+
+func newRateLimiter(cfg *config.Config, postgres *db.Postgres) *transport.RateLimiter {
+	var store right.RateLimitStore = ratelimit.NewMemoryStore()
+	if cfg.RateLimit.Store == config.RateLimitPostgres {
+		store = db.NewRateLimitStore(postgres.Conn)
+	}
+
+	limits := transport.RateLimits{
+		Posts:    domain.RateLimit(cfg.RateLimit.Posts),
+		Comments: domain.RateLimit(cfg.RateLimit.Comments),
+		Images:   domain.RateLimit(cfg.RateLimit.Images),
+		Logins:   domain.RateLimit(cfg.RateLimit.Logins),
 	}
+	return transport.NewRateLimiter(store, limits, cfg.RateLimit.TrustedProxies, cfg.HTTP.MaxUploadSize)
 }
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"1337b04rd/internal/adapters/right/db"
+	"1337b04rd/internal/config"
+)
+
+const migrateUsage = `usage: 1337b04rd migrate <up|down|status> [flags]
+
+  up      apply all pending migrations
+  down    revert the last applied migrations (see -steps)
+  status  list migrations and whether they are applied
+`
+
+// runMigrate реализует подкоманду migrate
+func runMigrate(cfg *config.Config, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing migrate command\n%s", migrateUsage)
+	}
+
+	fs := flag.NewFlagSet("migrate "+args[0], flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "only print what would be done")
+	steps := fs.Int("steps", 1, "number of migrations to revert (down only)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	postgres, err := db.NewPostgres(cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer postgres.Close()
+
+	migrator, err := postgres.Migrator(cfg.Migrations.Dir)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	prefix := ""
+	if *dryRun {
+		prefix = "[dry-run] "
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx, *dryRun)
+		for _, m := range applied {
+			fmt.Fprintf(out, "%sapplied %04d_%s\n", prefix, m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Fprintln(out, "database is up to date")
+		}
+	case "down":
+		reverted, err := migrator.Down(ctx, *steps, *dryRun)
+		for _, m := range reverted {
+			fmt.Fprintf(out, "%sreverted %04d_%s\n", prefix, m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(out, "%04d_%-30s %s\n", s.Version, s.Name, state)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", args[0], migrateUsage)
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"1337b04rd/internal/adapters/right/api"
+	"1337b04rd/internal/adapters/right/avatars"
+	"1337b04rd/internal/adapters/right/db"
+	"1337b04rd/internal/config"
+	"1337b04rd/internal/domain"
+)
+
+// seedCharacter — формат записи в файле персонажей, совпадает с ответом Rick and Morty API
+type seedCharacter struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Image string `json:"image"`
+}
+
+// runSeedAvatars реализует подкоманду seed-avatars: заполняет кэш персонажей из JSON-файла,
+// чтобы новые пользователи получали аватары и без доступа к API
+func runSeedAvatars(cfg *config.Config, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("seed-avatars", flag.ContinueOnError)
+	file := fs.String("file", "assets/characters.json", "JSON array of characters with id, name and image")
+	fetchImages := fs.Bool("fetch-images", true, "download avatars into the image storage")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		return fmt.Errorf("read characters: %w", err)
+	}
+	var records []seedCharacter
+	if err := json.Unmarshal(data, &records); err != nil {
+		return fmt.Errorf("parse %s: %w", *file, err)
+	}
+	characters := make([]domain.Character, 0, len(records))
+	for _, r := range records {
+		characters = append(characters, domain.Character{ID: r.ID, Name: r.Name, ImageURL: r.Image})
+	}
+
+	postgres, err := db.NewPostgres(cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer postgres.Close()
+
+	imageStorage, _, err := newImageStorage(cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	cache := avatars.NewCache(api.NewRickAndMortyAPI(), postgres, imageStorage)
+	added, err := cache.Seed(ctx, characters, *fetchImages)
+	fmt.Fprintf(out, "cached %d of %d characters\n", added, len(characters))
+	return err
+}
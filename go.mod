@@ -6,6 +6,7 @@ require (
 	github.com/jackc/pgx v3.6.2+incompatible
 	github.com/jackc/pgx/v5 v5.7.4
 	github.com/minio/minio-go/v7 v7.0.91
+	golang.org/x/image v0.25.0
 )
 
 require (
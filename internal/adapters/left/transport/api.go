@@ -0,0 +1,395 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"time"
+
+	"1337b04rd/internal/domain"
+)
+
+// JSON API (/api/v1) работает поверх того же left.APIPort и той же сессионной cookie,
+// что и HTML-обработчики.
+
+type postSummaryResponse struct {
+	ID           string    `json:"id"`
+	Title        string    `json:"title"`
+	Author       string    `json:"author"`
+	ImageURL     string    `json:"image_url,omitempty"`
+	ThumbnailURL string    `json:"thumbnail_url,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	Locked       bool      `json:"locked,omitempty"`
+	Pinned       bool      `json:"pinned,omitempty"`
+}
+
+type attachmentResponse struct {
+	ID           string `json:"id"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+	Width        int    `json:"width,omitempty"`
+	Height       int    `json:"height,omitempty"`
+	Size         int64  `json:"size,omitempty"`
+	MIMEType     string `json:"mime_type,omitempty"`
+}
+
+type commentResponse struct {
+	ID          string               `json:"id"`
+	ParentID    string               `json:"parent_id,omitempty"`
+	Author      string               `json:"author"`
+	AvatarURL   string               `json:"avatar_url,omitempty"`
+	Content     string               `json:"content"`
+	Attachments []attachmentResponse `json:"attachments"`
+	CreatedAt   time.Time            `json:"created_at"`
+	Replies     []commentResponse    `json:"replies"`
+}
+
+type postResponse struct {
+	ID          string               `json:"id"`
+	Title       string               `json:"title"`
+	Content     string               `json:"content"`
+	Author      string               `json:"author"`
+	AvatarURL   string               `json:"avatar_url,omitempty"`
+	ImageURL    string               `json:"image_url,omitempty"`
+	Thumbnail   string               `json:"thumbnail_url,omitempty"`
+	Attachments []attachmentResponse `json:"attachments"`
+	CreatedAt   time.Time            `json:"created_at"`
+	ExpiresAt   *time.Time           `json:"expires_at,omitempty"`
+	Locked      bool                 `json:"locked,omitempty"`
+	Pinned      bool                 `json:"pinned,omitempty"`
+	Comments    []commentResponse    `json:"comments"`
+}
+
+type commentRequest struct {
+	Content         string `json:"content"`
+	ParentCommentID string `json:"parent_comment_id"`
+}
+
+type userResponse struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	AvatarURL string `json:"avatar_url,omitempty"`
+}
+
+type updateUserRequest struct {
+	Name string `json:"name"`
+}
+
+type errorResponse struct {
+	Error errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (h *Handler) APIListCatalog(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	catalog, err := h.service.GetCatalog(ctx)
+	if err != nil {
+		writeJSONError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newPostSummaryResponses(catalog))
+}
+
+func (h *Handler) APIGetPost(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	post, err := h.service.GetPostByID(ctx, r.PathValue("id"))
+	if err != nil {
+		writeJSONError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newPostResponse(post))
+}
+
+func (h *Handler) APIListArchive(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	archive, err := h.service.GetArchiveList(ctx)
+	if err != nil {
+		writeJSONError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newPostSummaryResponses(archive))
+}
+
+func (h *Handler) APIGetArchivedPost(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	post, err := h.service.GetArchivedPostByID(ctx, r.PathValue("id"))
+	if err != nil {
+		writeJSONError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newPostResponse(post))
+}
+
+func (h *Handler) APICreatePost(w http.ResponseWriter, r *http.Request) {
+	session, ok := r.Context().Value(SessionKey).(*domain.Session)
+	if !ok || session == nil {
+		writeJSONError(w, &requestError{Status: http.StatusUnauthorized, Message: "Unauthorized"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	post, err := h.submitPost(ctx, r, session)
+	if err != nil {
+		writeJSONError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/posts/"+post.ID)
+	writeJSON(w, http.StatusCreated, newPostResponse(post))
+}
+
+func (h *Handler) APIAddComment(w http.ResponseWriter, r *http.Request) {
+	session, ok := r.Context().Value(SessionKey).(*domain.Session)
+	if !ok || session == nil {
+		writeJSONError(w, &requestError{Status: http.StatusUnauthorized, Message: "Unauthorized"})
+		return
+	}
+
+	req, files, err := h.decodeCommentRequest(r)
+	if err != nil {
+		writeJSONError(w, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	comment, err := h.submitComment(ctx, session, r.PathValue("id"), req.ParentCommentID, req.Content, files)
+	if err != nil {
+		writeJSONError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newCommentResponse(*comment))
+}
+
+func (h *Handler) APIGetMe(w http.ResponseWriter, r *http.Request) {
+	session, ok := r.Context().Value(SessionKey).(*domain.Session)
+	if !ok || session == nil {
+		writeJSONError(w, &requestError{Status: http.StatusUnauthorized, Message: "Unauthorized"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	user, err := h.service.GetUser(ctx, session.UserID)
+	if err != nil {
+		writeJSONError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newUserResponse(user))
+}
+
+// APIUpdateMe меняет имя текущего пользователя; аватар не меняется
+func (h *Handler) APIUpdateMe(w http.ResponseWriter, r *http.Request) {
+	session, ok := r.Context().Value(SessionKey).(*domain.Session)
+	if !ok || session == nil {
+		writeJSONError(w, &requestError{Status: http.StatusUnauthorized, Message: "Unauthorized"})
+		return
+	}
+
+	var req updateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, &requestError{Status: http.StatusBadRequest, Message: "Invalid JSON body"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	user, err := h.service.SetUsername(ctx, session.UserID, req.Name)
+	if err != nil {
+		writeJSONError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newUserResponse(user))
+}
+
+// decodeCommentRequest принимает комментарий как JSON или как форму;
+// изображения можно передать только в multipart-форме
+func (h *Handler) decodeCommentRequest(r *http.Request) (*commentRequest, []*multipart.FileHeader, error) {
+	var req commentRequest
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, nil, &requestError{Status: http.StatusBadRequest, Message: "Invalid JSON body"}
+		}
+		return &req, nil, nil
+	}
+
+	if err := h.parseForm(r); err != nil {
+		return nil, nil, err
+	}
+	req.Content = r.FormValue("content")
+	req.ParentCommentID = r.FormValue("parent_comment_id")
+	return &req, formImages(r.MultipartForm), nil
+}
+
+func newUserResponse(u *domain.User) userResponse {
+	return userResponse{
+		ID:        u.ID,
+		Name:      u.Username,
+		AvatarURL: u.ImageURL,
+	}
+}
+
+func newPostSummaryResponses(posts []*domain.PostSummary) []postSummaryResponse {
+	resp := make([]postSummaryResponse, 0, len(posts))
+	for _, p := range posts {
+		resp = append(resp, postSummaryResponse{
+			ID:           p.ID,
+			Title:        p.Title,
+			Author:       p.Author,
+			ImageURL:     p.ImageURL,
+			ThumbnailURL: p.ThumbnailURL,
+			CreatedAt:    p.CreatedAt,
+			Locked:       p.Locked,
+			Pinned:       p.Pinned,
+		})
+	}
+	return resp
+}
+
+func newPostResponse(p *domain.Post) postResponse {
+	resp := postResponse{
+		ID:          p.ID,
+		Title:       p.Title,
+		Content:     p.Content,
+		Author:      p.Author,
+		AvatarURL:   p.UserAvatar,
+		ImageURL:    p.ImageURL,
+		Thumbnail:   p.ThumbnailURL,
+		Attachments: newAttachmentResponses(p.Attachments),
+		CreatedAt:   p.CreatedAt,
+		Locked:      p.Locked,
+		Pinned:      p.Pinned,
+		Comments:    newCommentResponses(p.Comments),
+	}
+	if !p.ExpiresAt.IsZero() {
+		resp.ExpiresAt = &p.ExpiresAt
+	}
+	return resp
+}
+
+func newCommentResponses(comments []domain.Comment) []commentResponse {
+	resp := make([]commentResponse, 0, len(comments))
+	for _, c := range comments {
+		resp = append(resp, newCommentResponse(c))
+	}
+	return resp
+}
+
+func newCommentResponse(c domain.Comment) commentResponse {
+	return commentResponse{
+		ID:          c.ID,
+		ParentID:    c.ParentID,
+		Author:      c.Author,
+		AvatarURL:   c.AvatarLink,
+		Content:     c.Content,
+		Attachments: newAttachmentResponses(c.Attachments),
+		CreatedAt:   c.CreatedAt,
+		Replies:     newCommentResponses(c.Replies),
+	}
+}
+
+func newAttachmentResponses(attachments []domain.Attachment) []attachmentResponse {
+	resp := make([]attachmentResponse, 0, len(attachments))
+	for _, a := range attachments {
+		resp = append(resp, attachmentResponse{
+			ID:           a.ID,
+			URL:          a.URL,
+			ThumbnailURL: a.ThumbnailURL,
+			Width:        a.Width,
+			Height:       a.Height,
+			Size:         a.Size,
+			MIMEType:     a.MIMEType,
+		})
+	}
+	return resp
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("Failed to encode JSON response", "error", err)
+	}
+}
+
+// writeJSONError отдаёт ошибку в едином формате {"error": {"code": ..., "message": ...}}
+func writeJSONError(w http.ResponseWriter, err error) {
+	status, body := errorStatus(err)
+	if status == http.StatusInternalServerError {
+		slog.Error("API request failed", "error", err)
+	}
+	writeJSON(w, status, errorResponse{Error: body})
+}
+
+func errorStatus(err error) (int, errorBody) {
+	var reqErr *requestError
+	switch {
+	case errors.As(err, &reqErr):
+		return reqErr.Status, errorBody{Code: codeForStatus(reqErr.Status), Message: reqErr.Message}
+	case errors.Is(err, domain.ErrInvalidUsername):
+		return http.StatusBadRequest, errorBody{Code: "invalid_username", Message: err.Error()}
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound, errorBody{Code: "not_found", Message: "Resource not found"}
+	case errors.Is(err, domain.ErrPostArchived):
+		return http.StatusConflict, errorBody{Code: "post_archived", Message: "Post is archived"}
+	case errors.Is(err, domain.ErrPostLocked):
+		return http.StatusConflict, errorBody{Code: "post_locked", Message: "Post is locked"}
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, errorBody{Code: "timeout", Message: "Request timed out"}
+	default:
+		return http.StatusInternalServerError, errorBody{Code: "internal", Message: "Internal Server Error"}
+	}
+}
+
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "bad_request"
+	case http.StatusUnauthorized:
+		return "unauthorized"
+	case http.StatusForbidden:
+		return "forbidden"
+	case http.StatusNotFound:
+		return "not_found"
+	case http.StatusRequestEntityTooLarge:
+		return "too_large"
+	case http.StatusUnsupportedMediaType:
+		return "unsupported_media_type"
+	case http.StatusUnprocessableEntity:
+		return "invalid_image"
+	case http.StatusTooManyRequests:
+		return "rate_limited"
+	default:
+		return "error"
+	}
+}
@@ -0,0 +1,380 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"1337b04rd/internal/domain"
+	"1337b04rd/internal/ports/left"
+)
+
+type fakeService struct {
+	left.APIPort
+	posts    map[string]*domain.Post
+	comments []*domain.Comment
+	uploads  int
+	username string
+}
+
+func (s *fakeService) GetPostByID(ctx context.Context, id string) (*domain.Post, error) {
+	post, ok := s.posts[id]
+	if !ok {
+		return nil, fmt.Errorf("get post by id: %w", domain.ErrNotFound)
+	}
+	return post, nil
+}
+
+func (s *fakeService) AddComment(ctx context.Context, postID string, comment *domain.Comment) error {
+	if _, ok := s.posts[postID]; !ok {
+		return domain.ErrPostArchived
+	}
+	s.comments = append(s.comments, comment)
+	return nil
+}
+
+func (s *fakeService) ReplyToComment(ctx context.Context, postID, parentCommentID string, reply *domain.Comment) error {
+	return fmt.Errorf("parent comment not found: %w", domain.ErrNotFound)
+}
+
+func (s *fakeService) CreatePost(ctx context.Context, post *domain.Post) error {
+	s.posts[post.ID] = post
+	return nil
+}
+
+func (s *fakeService) UploadImage(ctx context.Context, r io.Reader) (*domain.Image, error) {
+	s.uploads++
+	return &domain.Image{
+		ObjectName:  fmt.Sprintf("img%d.png", s.uploads),
+		ContentType: "image/png",
+		Thumbnails:  []string{fmt.Sprintf("img%d_200.jpg", s.uploads)},
+	}, nil
+}
+
+func (s *fakeService) GetUser(ctx context.Context, userID string) (*domain.User, error) {
+	return &domain.User{ID: userID, Username: s.username, ImageURL: "/images/avatar_1.jpg"}, nil
+}
+
+func (s *fakeService) SetUsername(ctx context.Context, userID, name string) (*domain.User, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, fmt.Errorf("%w: name is empty", domain.ErrInvalidUsername)
+	}
+	s.username = name
+	return s.GetUser(ctx, userID)
+}
+
+func newAPITestMux(service left.APIPort) *http.ServeMux {
+	h := &Handler{
+		service:            service,
+		requestTimeout:     5 * time.Second,
+		maxUploadSize:      10 << 20,
+		maxAttachments:     2,
+		maxAttachmentsSize: 1 << 20,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/posts", h.APICreatePost)
+	mux.HandleFunc("GET /api/v1/posts/{id}", h.APIGetPost)
+	mux.HandleFunc("POST /api/v1/posts/{id}/comments", h.APIAddComment)
+	mux.HandleFunc("PATCH /api/v1/me", h.APIUpdateMe)
+	return mux
+}
+
+func TestAPIGetPost(t *testing.T) {
+	service := &fakeService{posts: map[string]*domain.Post{
+		"p1": {ID: "p1", Title: "Hello", Comments: []domain.Comment{
+			{ID: "c1", Content: "root", Replies: []domain.Comment{{ID: "c2", ParentID: "c1", Content: "reply"}}},
+		}},
+	}}
+	mux := newAPITestMux(service)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/posts/p1", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body)
+	}
+	var post postResponse
+	if err := json.NewDecoder(rec.Body).Decode(&post); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if post.Title != "Hello" || len(post.Comments) != 1 || post.Comments[0].Replies[0].ID != "c2" {
+		t.Fatalf("unexpected post: %+v", post)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/posts/missing", nil))
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404", rec.Code)
+	}
+	var errResp errorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&errResp); err != nil || errResp.Error.Code != "not_found" {
+		t.Fatalf("unexpected error body: %+v (%v)", errResp, err)
+	}
+}
+
+func TestAPIAddComment(t *testing.T) {
+	service := &fakeService{posts: map[string]*domain.Post{"p1": {ID: "p1"}}}
+	mux := newAPITestMux(service)
+	session := &domain.Session{ID: "s1", UserID: "u1"}
+
+	tests := []struct {
+		name   string
+		postID string
+		body   string
+		status int
+	}{
+		{name: "created", postID: "p1", body: `{"content":"hi"}`, status: http.StatusCreated},
+		{name: "empty content", postID: "p1", body: `{"content":""}`, status: http.StatusBadRequest},
+		{name: "archived post", postID: "p2", body: `{"content":"hi"}`, status: http.StatusConflict},
+		{name: "bad json", postID: "p1", body: `{`, status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/posts/"+tt.postID+"/comments", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req = req.WithContext(context.WithValue(req.Context(), SessionKey, session))
+
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d, body = %s", rec.Code, tt.status, rec.Body)
+			}
+			if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+				t.Fatalf("content type = %q", ct)
+			}
+		})
+	}
+}
+
+func TestHandleAddComment_ErrorStatus(t *testing.T) {
+	h := &Handler{
+		service:        &fakeService{posts: map[string]*domain.Post{"p1": {ID: "p1"}}},
+		requestTimeout: 5 * time.Second,
+		maxUploadSize:  10 << 20,
+	}
+	session := &domain.Session{ID: "s1", UserID: "u1"}
+
+	tests := []struct {
+		name   string
+		postID string
+		form   string
+		status int
+	}{
+		{name: "created", postID: "p1", form: "content=hi", status: http.StatusSeeOther},
+		{name: "archived post", postID: "p2", form: "content=hi", status: http.StatusConflict},
+		{name: "unknown parent", postID: "p1", form: "content=hi&parent_comment_id=not-a-uuid", status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/post/submit-comment?id="+tt.postID, strings.NewReader(tt.form))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req = req.WithContext(context.WithValue(req.Context(), SessionKey, session))
+
+			rec := httptest.NewRecorder()
+			h.HandleAddComment(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d, body = %s", rec.Code, tt.status, rec.Body)
+			}
+			if strings.Contains(rec.Body.String(), "not found:") || strings.Contains(rec.Body.String(), "archived:") {
+				t.Fatalf("internal error leaked: %s", rec.Body)
+			}
+		})
+	}
+}
+
+func newPostForm(t *testing.T, images int) (*bytes.Buffer, string) {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	w.WriteField("title", "Hello")
+	w.WriteField("content", "text")
+	for i := 0; i < images; i++ {
+		part, err := w.CreateFormFile("images", fmt.Sprintf("%d.png", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write([]byte("png"))
+	}
+	// Браузер отправляет пустую часть, если файл не выбран
+	if _, err := w.CreateFormFile("image", ""); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	return &body, w.FormDataContentType()
+}
+
+func TestAPICreatePost_Attachments(t *testing.T) {
+	session := &domain.Session{ID: "s1", UserID: "u1"}
+
+	tests := []struct {
+		name        string
+		images      int
+		status      int
+		attachments int
+	}{
+		{name: "text only", images: 0, status: http.StatusCreated},
+		{name: "two images", images: 2, status: http.StatusCreated, attachments: 2},
+		{name: "too many images", images: 3, status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeService{posts: map[string]*domain.Post{}}
+			mux := newAPITestMux(service)
+
+			body, contentType := newPostForm(t, tt.images)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/posts", body)
+			req.Header.Set("Content-Type", contentType)
+			req = req.WithContext(context.WithValue(req.Context(), SessionKey, session))
+
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d, body = %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status != http.StatusCreated {
+				if service.uploads != 0 {
+					t.Errorf("%d images uploaded despite rejection", service.uploads)
+				}
+				return
+			}
+
+			var post postResponse
+			if err := json.NewDecoder(rec.Body).Decode(&post); err != nil {
+				t.Fatal(err)
+			}
+			if len(post.Attachments) != tt.attachments {
+				t.Fatalf("attachments = %+v, want %d", post.Attachments, tt.attachments)
+			}
+			if tt.attachments > 0 && (post.ImageURL != "/images/img1.png" || post.Thumbnail != "/images/img1_200.jpg") {
+				t.Errorf("cover = %q / %q", post.ImageURL, post.Thumbnail)
+			}
+		})
+	}
+}
+
+func TestAPIAddComment_Attachments(t *testing.T) {
+	session := &domain.Session{ID: "s1", UserID: "u1"}
+
+	tests := []struct {
+		name    string
+		postID  string
+		content string
+		images  int
+		status  int
+	}{
+		{name: "image only", postID: "p1", images: 1, status: http.StatusCreated},
+		{name: "text and images", postID: "p1", content: "hi", images: 2, status: http.StatusCreated},
+		{name: "empty", postID: "p1", status: http.StatusBadRequest},
+		{name: "archived post", postID: "p2", content: "hi", images: 1, status: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeService{posts: map[string]*domain.Post{"p1": {ID: "p1"}}}
+			mux := newAPITestMux(service)
+
+			var body bytes.Buffer
+			w := multipart.NewWriter(&body)
+			w.WriteField("content", tt.content)
+			for i := 0; i < tt.images; i++ {
+				part, err := w.CreateFormFile("images", fmt.Sprintf("%d.png", i))
+				if err != nil {
+					t.Fatal(err)
+				}
+				part.Write([]byte("png"))
+			}
+			w.Close()
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/posts/"+tt.postID+"/comments", &body)
+			req.Header.Set("Content-Type", w.FormDataContentType())
+			req = req.WithContext(context.WithValue(req.Context(), SessionKey, session))
+
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d, body = %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status != http.StatusCreated {
+				return
+			}
+
+			var comment commentResponse
+			if err := json.NewDecoder(rec.Body).Decode(&comment); err != nil {
+				t.Fatal(err)
+			}
+			if len(comment.Attachments) != tt.images {
+				t.Fatalf("attachments = %+v, want %d", comment.Attachments, tt.images)
+			}
+			if got := comment.Attachments[0].ThumbnailURL; got != "/images/img1_200.jpg" {
+				t.Errorf("thumbnail = %q", got)
+			}
+		})
+	}
+}
+
+func TestAPIUpdateMe(t *testing.T) {
+	service := &fakeService{username: "Rick Sanchez"}
+	mux := newAPITestMux(service)
+	session := &domain.Session{ID: "s1", UserID: "u1"}
+
+	tests := []struct {
+		name    string
+		body    string
+		session *domain.Session
+		status  int
+		code    string
+	}{
+		{name: "renamed", body: `{"name":"Tiny Rick"}`, session: session, status: http.StatusOK},
+		{name: "invalid name", body: `{"name":" "}`, session: session, status: http.StatusBadRequest, code: "invalid_username"},
+		{name: "bad json", body: `{`, session: session, status: http.StatusBadRequest, code: "bad_request"},
+		{name: "no session", body: `{"name":"Morty"}`, status: http.StatusUnauthorized, code: "unauthorized"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/api/v1/me", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.session != nil {
+				req = req.WithContext(context.WithValue(req.Context(), SessionKey, tt.session))
+			}
+
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d, body = %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.code != "" {
+				var errResp errorResponse
+				if err := json.NewDecoder(rec.Body).Decode(&errResp); err != nil || errResp.Error.Code != tt.code {
+					t.Fatalf("unexpected error body: %+v (%v)", errResp, err)
+				}
+				return
+			}
+
+			var user userResponse
+			if err := json.NewDecoder(rec.Body).Decode(&user); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if user.Name != "Tiny Rick" || user.AvatarURL != "/images/avatar_1.jpg" {
+				t.Fatalf("unexpected user: %+v", user)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package transport
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"1337b04rd/internal/domain"
+)
+
+const (
+	csrfFormField = "csrf_token"
+	csrfHeader    = "X-CSRF-Token"
+)
+
+// CSRF выдаёт и проверяет токены, привязанные к сессии: токен — HMAC идентификатора
+// сессии, поэтому его не нужно хранить, а чужой сайт не может его подобрать.
+type CSRF struct {
+	secret []byte
+}
+
+// NewCSRF создаёт генератор токенов. Если secret пуст, берётся случайный ключ:
+// токены тогда перестают действовать после перезапуска и различаются между репликами.
+func NewCSRF(secret string) *CSRF {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			panic("csrf: generate secret: " + err.Error())
+		}
+	}
+	return &CSRF{secret: key}
+}
+
+func (c *CSRF) Token(sessionID string) string {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte("csrf:" + sessionID))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (c *CSRF) Valid(sessionID, token string) bool {
+	return token != "" && hmac.Equal([]byte(token), []byte(c.Token(sessionID)))
+}
+
+// CheckCSRF защищает небезопасные методы от межсайтовых запросов.
+//   - Заголовки Origin и Referer, если они есть, должны указывать на этот же хост.
+//   - Формы с сессией должны содержать токен в поле csrf_token или заголовке X-CSRF-Token.
+//   - Формы без сессии (первый комментарий анонима) должны прийти с Origin или Referer.
+//   - JSON API проверяется только по Origin/Referer: его клиенты токен не получают.
+//
+// Форма разбирается здесь же с ограничением maxBodySize; обработчики повторно её не читают.
+func CheckCSRF(csrf *CSRF, maxBodySize int64) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isSafeMethod(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			sameOrigin, present := checkOrigin(r)
+			if present && !sameOrigin {
+				rejectCSRF(w, r, "cross-site request")
+				return
+			}
+			if strings.HasPrefix(r.URL.Path, "/api/") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			session, ok := r.Context().Value(SessionKey).(*domain.Session)
+			if !ok || session == nil {
+				if !present {
+					rejectCSRF(w, r, "missing Origin and Referer")
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token := r.Header.Get(csrfHeader)
+			if token == "" {
+				if err := parseForm(r, maxBodySize); err != nil {
+					var reqErr *requestError
+					errors.As(err, &reqErr)
+					http.Error(w, reqErr.Message, reqErr.Status)
+					return
+				}
+				token = r.FormValue(csrfFormField)
+			}
+			if !csrf.Valid(session.ID, token) {
+				rejectCSRF(w, r, "invalid token")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// checkOrigin сравнивает хост из Origin (или, если его нет, из Referer) с хостом запроса.
+// present — был ли хотя бы один из заголовков.
+func checkOrigin(r *http.Request) (sameOrigin, present bool) {
+	source := r.Header.Get("Origin")
+	if source == "" {
+		source = r.Header.Get("Referer")
+	}
+	if source == "" {
+		return false, false
+	}
+
+	u, err := url.Parse(source)
+	if err != nil || u.Host == "" {
+		// В том числе Origin: null из sandbox-фреймов и file://
+		return false, true
+	}
+	return strings.EqualFold(u.Host, r.Host), true
+}
+
+func rejectCSRF(w http.ResponseWriter, r *http.Request, reason string) {
+	slog.Warn("CSRF check failed", "reason", reason, "method", r.Method, "path", r.URL.Path)
+	if strings.HasPrefix(r.URL.Path, "/api/") {
+		writeJSONError(w, &requestError{Status: http.StatusForbidden, Message: "Cross-site request rejected"})
+		return
+	}
+	http.Error(w, "Cross-site request rejected", http.StatusForbidden)
+}
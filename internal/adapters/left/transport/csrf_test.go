@@ -0,0 +1,102 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"1337b04rd/internal/domain"
+)
+
+func TestCheckCSRF(t *testing.T) {
+	csrf := NewCSRF(strings.Repeat("k", 32))
+	session := &domain.Session{ID: "s1", UserID: "u1"}
+	valid := csrf.Token(session.ID)
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		session *domain.Session
+		origin  string
+		referer string
+		token   string
+		status  int
+	}{
+		{name: "safe method", method: http.MethodGet, path: "/catalog", origin: "https://evil.example", status: http.StatusOK},
+		{name: "form with token", method: http.MethodPost, path: "/submit-post", session: session, token: valid, status: http.StatusOK},
+		{name: "form without token", method: http.MethodPost, path: "/submit-post", session: session, origin: "http://board.example", status: http.StatusForbidden},
+		{name: "token of another session", method: http.MethodPost, path: "/submit-post", session: session, token: csrf.Token("s2"), status: http.StatusForbidden},
+		{name: "cross-site form with token", method: http.MethodPost, path: "/submit-post", session: session, token: valid, origin: "https://evil.example", status: http.StatusForbidden},
+		{name: "first comment", method: http.MethodPost, path: "/post/submit-comment", origin: "http://board.example", status: http.StatusOK},
+		{name: "first comment by referer", method: http.MethodPost, path: "/post/submit-comment", referer: "http://board.example/post/1", status: http.StatusOK},
+		{name: "first comment without origin", method: http.MethodPost, path: "/post/submit-comment", status: http.StatusForbidden},
+		{name: "api without origin", method: http.MethodPost, path: "/api/v1/posts", session: session, status: http.StatusOK},
+		{name: "api same origin", method: http.MethodPatch, path: "/api/v1/me", session: session, origin: "http://board.example", status: http.StatusOK},
+		{name: "api cross-site", method: http.MethodPost, path: "/api/v1/posts", session: session, origin: "https://evil.example", status: http.StatusForbidden},
+		{name: "api cross-site referer", method: http.MethodPost, path: "/api/v1/posts", session: session, referer: "https://evil.example/page", status: http.StatusForbidden},
+		{name: "api null origin", method: http.MethodPost, path: "/api/v1/posts", session: session, origin: "null", status: http.StatusForbidden},
+	}
+
+	handler := CheckCSRF(csrf, 1<<20)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{"content": {"hi"}}
+			if tt.token != "" {
+				form.Set(csrfFormField, tt.token)
+			}
+			req := httptest.NewRequest(tt.method, "http://board.example"+tt.path, strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.referer != "" {
+				req.Header.Set("Referer", tt.referer)
+			}
+			if tt.session != nil {
+				req = req.WithContext(context.WithValue(req.Context(), SessionKey, tt.session))
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d, body = %s", rec.Code, tt.status, rec.Body)
+			}
+		})
+	}
+}
+
+func TestCheckCSRF_MultipartFormIsReadOnce(t *testing.T) {
+	csrf := NewCSRF("")
+	session := &domain.Session{ID: "s1", UserID: "u1"}
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	w.WriteField(csrfFormField, csrf.Token(session.ID))
+	w.WriteField("content", "hello")
+	w.Close()
+
+	var content string
+	handler := CheckCSRF(csrf, 1<<20)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := parseForm(r, 1<<20); err != nil {
+			t.Errorf("parse form again: %v", err)
+		}
+		content = r.FormValue("content")
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/post/submit-comment", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	req = req.WithContext(context.WithValue(req.Context(), SessionKey, session))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || content != "hello" {
+		t.Fatalf("status = %d, content = %q", rec.Code, content)
+	}
+}
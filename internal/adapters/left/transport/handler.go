@@ -4,12 +4,16 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"html/template"
 	"log/slog"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"strings"
 	"time"
 
+	"1337b04rd/internal/config"
 	"1337b04rd/internal/domain"
 	"1337b04rd/internal/ports/left"
 	"1337b04rd/internal/ports/right"
@@ -18,24 +22,38 @@ import (
 )
 
 type Handler struct {
-	service      left.APIPort
-	templates    *template.Template
-	imageStorage right.ImageStorage
-	logger       *logger.CustomLogger
+	service        left.APIPort
+	templates      *template.Template
+	imageStorage   right.ImageStorage
+	logger         *logger.CustomLogger
+	requestTimeout time.Duration
+	maxUploadSize  int64
+	// ограничения на вложения одного поста или комментария
+	maxAttachments     int
+	maxAttachmentsSize int64
+	csrf               *CSRF
+	// moderators — nil, если модерация не настроена
+	moderators *ModeratorAuth
 }
 
-func NewPostHandler(postService left.APIPort, logger *logger.CustomLogger, imageStorage right.ImageStorage) *Handler {
+func NewPostHandler(postService left.APIPort, logger *logger.CustomLogger, imageStorage right.ImageStorage, cfg config.HTTPConfig, csrf *CSRF, moderators *ModeratorAuth) *Handler {
 	tmpl := template.Must(template.ParseGlob("web/templates/*.html"))
 	return &Handler{
-		service:      postService,
-		templates:    tmpl,
-		imageStorage: imageStorage,
-		logger:       logger,
+		service:            postService,
+		templates:          tmpl,
+		imageStorage:       imageStorage,
+		logger:             logger,
+		requestTimeout:     cfg.RequestTimeout,
+		maxUploadSize:      cfg.MaxUploadSize,
+		maxAttachments:     cfg.MaxAttachments,
+		maxAttachmentsSize: cfg.MaxAttachmentsSize,
+		csrf:               csrf,
+		moderators:         moderators,
 	}
 }
 
 func (h *Handler) HandleCatalog(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
 	defer cancel()
 
 	data, err := h.service.GetCatalog(ctx)
@@ -60,7 +78,7 @@ func (h *Handler) HandleCatalog(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) HandleGetPost(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
 	defer cancel()
 
 	data, err := h.service.GetPostByID(ctx, id)
@@ -75,9 +93,12 @@ func (h *Handler) HandleGetPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	_, moderator := moderatorName(r)
+	page := postPage{Post: data, CSRFToken: h.csrfToken(r), Moderator: moderator, Path: r.URL.Path}
+
 	// Используем буфер для безопасного рендеринга шаблона
 	var buf bytes.Buffer
-	if err := h.templates.ExecuteTemplate(&buf, "post.html", data); err != nil {
+	if err := h.templates.ExecuteTemplate(&buf, "post.html", page); err != nil {
 		slog.Error("Failed to render template", "error", err)
 		http.Error(w, "Render error", http.StatusInternalServerError)
 		return
@@ -101,9 +122,11 @@ func (h *Handler) HandleCreatePostForm(w http.ResponseWriter, r *http.Request) {
 
 	// Добавляем данные, если нужно
 	data := struct {
-		Title string
+		Title     string
+		CSRFToken string
 	}{
-		Title: "Create New Post",
+		Title:     "Create New Post",
+		CSRFToken: h.csrfToken(r),
 	}
 
 	// Рендерим шаблон
@@ -116,25 +139,112 @@ func (h *Handler) HandleCreatePostForm(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) HandleSubmitPost(w http.ResponseWriter, r *http.Request) {
-	if err := r.ParseMultipartForm(10 << 20); err != nil {
-		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+	session, ok := r.Context().Value(SessionKey).(*domain.Session)
+	if !ok || session == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	title := r.FormValue("title")
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	if _, err := h.submitPost(ctx, r, session); err != nil {
+		var reqErr *requestError
+		if errors.As(err, &reqErr) {
+			http.Error(w, reqErr.Message, reqErr.Status)
+			return
+		}
+
+		slog.Error("Post creation failed", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/catalog", http.StatusSeeOther)
+}
+
+func (h *Handler) HandleAddComment(w http.ResponseWriter, r *http.Request) {
+	if err := h.parseForm(r); err != nil {
+		var reqErr *requestError
+		errors.As(err, &reqErr)
+		http.Error(w, reqErr.Message, reqErr.Status)
+		return
+	}
+
+	session, ok := r.Context().Value(SessionKey).(*domain.Session)
+	if !ok || session == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	postID := r.URL.Query().Get("id")
+	parentID := r.FormValue("parent_comment_id")
 	content := r.FormValue("content")
 
-	if title == "" || content == "" {
-		http.Error(w, "Title and content are required", http.StatusBadRequest)
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	if _, err := h.submitComment(ctx, session, postID, parentID, content, formImages(r.MultipartForm)); err != nil {
+		// Статусы те же, что и в API; внутренние ошибки только логируются
+		status, body := errorStatus(err)
+		if status == http.StatusInternalServerError {
+			slog.Error("Failed to add comment", "post_id", postID, "error", err)
+			body.Message = "Failed to add comment"
+		}
+		http.Error(w, body.Message, status)
 		return
 	}
 
-	file, header, err := r.FormFile("image")
+	http.Redirect(w, r, "/post/"+postID, http.StatusSeeOther)
+}
+
+// postPage — данные post.html и archive-post.html; поля поста доступны в шаблоне напрямую
+type postPage struct {
+	*domain.Post
+	CSRFToken string
+	// Moderator включает панель модератора
+	Moderator bool
+	Archived  bool
+	// Path — адрес страницы, на который панель модератора возвращает после действия
+	Path string
+}
+
+type profilePage struct {
+	User *domain.User
+	// Name — значение поля формы: текущее имя или отклонённое
+	Name      string
+	Error     string
+	CSRFToken string
+}
+
+func (h *Handler) HandleProfile(w http.ResponseWriter, r *http.Request) {
+	session, ok := r.Context().Value(SessionKey).(*domain.Session)
+	if !ok || session == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	user, err := h.service.GetUser(ctx, session.UserID)
 	if err != nil {
-		http.Error(w, "Failed to get image", http.StatusBadRequest)
+		slog.Error("GetUser error", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	h.renderProfile(w, http.StatusOK, profilePage{User: user, Name: user.Username, CSRFToken: h.csrfToken(r)})
+}
+
+// HandleSetUsername меняет имя пользователя; при ошибке проверки форма показывается снова
+func (h *Handler) HandleSetUsername(w http.ResponseWriter, r *http.Request) {
+	if err := h.parseForm(r); err != nil {
+		var reqErr *requestError
+		errors.As(err, &reqErr)
+		http.Error(w, reqErr.Message, reqErr.Status)
 		return
 	}
-	defer file.Close()
 
 	session, ok := r.Context().Value(SessionKey).(*domain.Session)
 	if !ok || session == nil {
@@ -142,14 +252,82 @@ func (h *Handler) HandleSubmitPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
 	defer cancel()
 
+	name := r.FormValue("name")
+	if _, err := h.service.SetUsername(ctx, session.UserID, name); err != nil {
+		if !errors.Is(err, domain.ErrInvalidUsername) {
+			slog.Error("SetUsername error", "error", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		user, getErr := h.service.GetUser(ctx, session.UserID)
+		if getErr != nil {
+			slog.Error("GetUser error", "error", getErr)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		h.renderProfile(w, http.StatusBadRequest, profilePage{User: user, Name: name, Error: err.Error(), CSRFToken: h.csrfToken(r)})
+		return
+	}
+
+	http.Redirect(w, r, "/profile", http.StatusSeeOther)
+}
+
+func (h *Handler) renderProfile(w http.ResponseWriter, status int, page profilePage) {
+	var buf bytes.Buffer
+	if err := h.templates.ExecuteTemplate(&buf, "profile.html", page); err != nil {
+		slog.Error("Failed to render template", "error", err)
+		http.Error(w, "Render error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		slog.Error("Failed to send response", "error", err)
+	}
+}
+
+// csrfToken возвращает токен для форм; без сессии формы отправляются без него,
+// и CheckCSRF проверяет только Origin/Referer
+func (h *Handler) csrfToken(r *http.Request) string {
+	session, ok := r.Context().Value(SessionKey).(*domain.Session)
+	if !ok || session == nil || h.csrf == nil {
+		return ""
+	}
+	return h.csrf.Token(session.ID)
+}
+
+// requestError — ошибка валидации запроса, которую можно показать клиенту как есть
+type requestError struct {
+	Status  int
+	Message string
+}
+
+func (e *requestError) Error() string {
+	return e.Message
+}
+
+// submitPost разбирает multipart-форму нового поста, загружает изображение и создаёт пост.
+// Используется и HTML-формой, и JSON API.
+func (h *Handler) submitPost(ctx context.Context, r *http.Request, session *domain.Session) (*domain.Post, error) {
+	if err := h.parseForm(r); err != nil {
+		return nil, err
+	}
+
+	title := r.FormValue("title")
+	content := r.FormValue("content")
+
+	if title == "" || content == "" {
+		return nil, &requestError{Status: http.StatusBadRequest, Message: "Title and content are required"}
+	}
+
 	postID, err := pkg.GenerateUUID()
 	if err != nil {
-		slog.Error("UUID generation failed", "error", err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-		return
+		return nil, fmt.Errorf("generate post id: %w", err)
 	}
 
 	post := &domain.Post{
@@ -160,48 +338,155 @@ func (h *Handler) HandleSubmitPost(w http.ResponseWriter, r *http.Request) {
 		CreatedAt: time.Now(),
 	}
 
-	// Загрузка изображения
-	objectName, err := h.imageStorage.UploadImage(ctx, file, header)
+	// Изображения необязательны: тип определяется по содержимому, метаданные удаляются
+	images, err := h.uploadImages(ctx, formImages(r.MultipartForm))
 	if err != nil {
-		slog.Error("Image upload failed", "error", err)
-		http.Error(w, "Failed to upload image", http.StatusInternalServerError)
-		return
+		return nil, err
+	}
+	if post.Attachments, err = newAttachments(images); err != nil {
+		return nil, err
+	}
+	// Первое изображение показывается в каталоге и архиве
+	if len(post.Attachments) > 0 {
+		post.ImageURL = post.Attachments[0].URL
+		post.ThumbnailURL = post.Attachments[0].ThumbnailURL
 	}
-	post.ImageURL = "/images/" + objectName
 
 	if err := h.service.CreatePost(ctx, post); err != nil {
-		slog.Error("Post creation failed", "error", err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-		return
+		return nil, fmt.Errorf("create post: %w", err)
 	}
 
-	http.Redirect(w, r, "/catalog", http.StatusSeeOther)
+	return post, nil
 }
 
-func (h *Handler) HandleAddComment(w http.ResponseWriter, r *http.Request) {
-	if err := r.ParseForm(); err != nil {
-		http.Error(w, "Invalid form data", http.StatusBadRequest)
-		return
+func (h *Handler) parseForm(r *http.Request) error {
+	return parseForm(r, h.maxUploadSize)
+}
+
+// parseForm разбирает обычную или multipart-форму, ограничивая размер тела запроса.
+// Уже разобранная форма повторно не читается. Ошибка всегда имеет тип *requestError.
+func parseForm(r *http.Request, maxBodySize int64) error {
+	r.Body = http.MaxBytesReader(nil, r.Body, maxBodySize)
+
+	var err error
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		err = r.ParseMultipartForm(maxBodySize)
+	} else {
+		err = r.ParseForm()
+	}
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return &requestError{Status: http.StatusRequestEntityTooLarge, Message: "Upload is too large"}
+		}
+		return &requestError{Status: http.StatusBadRequest, Message: "Failed to parse form"}
 	}
+	return nil
+}
 
-	session, ok := r.Context().Value(SessionKey).(*domain.Session)
-	if !ok || session == nil {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
+// formImages возвращает файлы из полей "images" и "image" (старое поле с одним файлом).
+// Пустые части, которые браузер отправляет без выбранного файла, пропускаются.
+func formImages(form *multipart.Form) []*multipart.FileHeader {
+	if form == nil {
+		return nil
 	}
+	var files []*multipart.FileHeader
+	for _, field := range []string{"images", "image"} {
+		for _, fh := range form.File[field] {
+			if fh.Filename == "" && fh.Size == 0 {
+				continue
+			}
+			files = append(files, fh)
+		}
+	}
+	return files
+}
 
-	postID := r.URL.Query().Get("id")
-	parentID := r.FormValue("parent_comment_id")
-	content := r.FormValue("content")
+// uploadImages проверяет число и общий размер файлов и загружает их по очереди.
+// Объекты, которые так и не попали в пост, удалит сборщик мусора хранилища.
+func (h *Handler) uploadImages(ctx context.Context, files []*multipart.FileHeader) ([]*domain.Image, error) {
+	if len(files) > h.maxAttachments {
+		return nil, &requestError{Status: http.StatusBadRequest, Message: fmt.Sprintf("At most %d images are allowed", h.maxAttachments)}
+	}
+	var total int64
+	for _, fh := range files {
+		total += fh.Size
+	}
+	if total > h.maxAttachmentsSize {
+		return nil, &requestError{Status: http.StatusRequestEntityTooLarge, Message: "Images are too large in total"}
+	}
 
-	if content == "" {
-		http.Error(w, "Content are required", http.StatusBadRequest)
-		return
+	images := make([]*domain.Image, 0, len(files))
+	for _, fh := range files {
+		img, err := h.uploadImage(ctx, fh)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, img)
+	}
+	return images, nil
+}
+
+func (h *Handler) uploadImage(ctx context.Context, fh *multipart.FileHeader) (*domain.Image, error) {
+	file, err := fh.Open()
+	if err != nil {
+		return nil, &requestError{Status: http.StatusBadRequest, Message: "Failed to read image"}
+	}
+	defer file.Close()
+
+	img, err := h.service.UploadImage(ctx, file)
+	if err != nil {
+		return nil, imageError(err)
+	}
+	return img, nil
+}
+
+// newAttachments описывает загруженные изображения как вложения с URL для шаблонов и API
+func newAttachments(images []*domain.Image) ([]domain.Attachment, error) {
+	attachments := make([]domain.Attachment, 0, len(images))
+	for _, img := range images {
+		id, err := pkg.GenerateUUID()
+		if err != nil {
+			return nil, fmt.Errorf("generate attachment id: %w", err)
+		}
+		a := domain.Attachment{
+			ID:       id,
+			URL:      domain.ImageURL(img.ObjectName),
+			Width:    img.Width,
+			Height:   img.Height,
+			Size:     img.Size,
+			MIMEType: img.ContentType,
+		}
+		if len(img.Thumbnails) > 0 {
+			a.ThumbnailURL = domain.ImageURL(img.Thumbnails[0])
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, nil
+}
+
+// imageError превращает отказ в приёме изображения в ошибку запроса с понятным статусом
+func imageError(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrUnsupportedImage):
+		return &requestError{Status: http.StatusUnsupportedMediaType, Message: "Only JPEG, PNG, GIF and WebP images are allowed"}
+	case errors.Is(err, domain.ErrInvalidImage):
+		return &requestError{Status: http.StatusUnprocessableEntity, Message: "Image is corrupt or too large"}
+	default:
+		return fmt.Errorf("upload image: %w", err)
+	}
+}
+
+// submitComment добавляет комментарий к посту или ответ на комментарий, если задан parentID.
+// Комментарий может состоять только из изображений.
+func (h *Handler) submitComment(ctx context.Context, session *domain.Session, postID, parentID, content string, files []*multipart.FileHeader) (*domain.Comment, error) {
+	if content == "" && len(files) == 0 {
+		return nil, &requestError{Status: http.StatusBadRequest, Message: "Content are required"}
 	}
 
 	uuid, err := pkg.GenerateUUID()
 	if err != nil {
-		slog.Error(err.Error())
+		return nil, fmt.Errorf("generate comment id: %w", err)
 	}
 
 	comment := &domain.Comment{
@@ -211,26 +496,30 @@ func (h *Handler) HandleAddComment(w http.ResponseWriter, r *http.Request) {
 		CreatedAt: time.Now(),
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	// Вложения проходят ту же проверку и получают те же превью, что и у постов
+	images, err := h.uploadImages(ctx, files)
+	if err != nil {
+		return nil, err
+	}
+	if comment.Attachments, err = newAttachments(images); err != nil {
+		return nil, err
+	}
 
 	if parentID != "" {
-		if err := h.service.ReplyToComment(ctx, parentID, comment); err != nil {
-			http.Error(w, "Failed to add reply: "+err.Error(), http.StatusInternalServerError)
-			return
+		if err := h.service.ReplyToComment(ctx, postID, parentID, comment); err != nil {
+			return nil, fmt.Errorf("add reply: %w", err)
 		}
 	} else {
 		if err := h.service.AddComment(ctx, postID, comment); err != nil {
-			http.Error(w, "Failed to add comment: "+err.Error(), http.StatusInternalServerError)
-			return
+			return nil, fmt.Errorf("add comment: %w", err)
 		}
 	}
 
-	http.Redirect(w, r, "/post/"+postID, http.StatusSeeOther)
+	return comment, nil
 }
 
 func (h *Handler) HandleArchiveList(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
 	defer cancel()
 
 	data, err := h.service.GetArchiveList(ctx)
@@ -256,7 +545,7 @@ func (h *Handler) HandleArchiveList(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) HandleGetArchivedPost(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
 	defer cancel()
 
 	data, err := h.service.GetArchivedPostByID(ctx, id)
@@ -271,15 +560,23 @@ func (h *Handler) HandleGetArchivedPost(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
+	_, moderator := moderatorName(r)
+	page := postPage{Post: data, CSRFToken: h.csrfToken(r), Moderator: moderator, Archived: true, Path: r.URL.Path}
 
-	if err := h.templates.ExecuteTemplate(w, "archive-post.html", data); err != nil {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := h.templates.ExecuteTemplate(w, "archive-post.html", page); err != nil {
 		slog.Error("Failed to render template", "error", err)
 		http.Error(w, "Render error", http.StatusInternalServerError)
 		return
 	}
 }
 
+// imageCacheControl — имена объектов не переиспользуются, поэтому изображения
+// можно кэшировать навсегда
+const imageCacheControl = "public, max-age=31536000, immutable"
+
+// ServeImage отдаёт изображение потоком. http.ServeContent выставляет Content-Length,
+// обрабатывает Range и условные запросы (If-None-Match, If-Modified-Since → 304).
 func (h *Handler) ServeImage(w http.ResponseWriter, r *http.Request) {
 	parts := strings.Split(r.URL.Path, "/")
 	if len(parts) < 3 {
@@ -288,12 +585,25 @@ func (h *Handler) ServeImage(w http.ResponseWriter, r *http.Request) {
 	}
 	imageName := parts[2]
 
-	data, contentType, err := h.imageStorage.GetImage(r.Context(), imageName)
+	obj, err := h.imageStorage.GetImage(r.Context(), imageName)
 	if err != nil {
+		if !errors.Is(err, domain.ErrNotFound) {
+			slog.Error("Failed to open image", "image", imageName, "error", err)
+		}
 		http.Error(w, "Image not found", http.StatusNotFound)
 		return
 	}
+	defer obj.Body.Close()
+
+	header := w.Header()
+	if obj.ContentType != "" {
+		header.Set("Content-Type", obj.ContentType)
+	}
+	if obj.ETag != "" {
+		header.Set("ETag", obj.ETag)
+	}
+	header.Set("Cache-Control", imageCacheControl)
+	header.Set("X-Content-Type-Options", "nosniff")
 
-	w.Header().Set("Content-Type", contentType)
-	w.Write(data)
+	http.ServeContent(w, r, imageName, obj.ModTime, obj.Body)
 }
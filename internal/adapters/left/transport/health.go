@@ -0,0 +1,142 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HealthCheck проверяет доступность одной зависимости
+type HealthCheck func(ctx context.Context) error
+
+const (
+	stateStarting int32 = iota
+	stateReady
+	stateShuttingDown
+)
+
+const healthCheckTimeout = 2 * time.Second
+
+// Health обслуживает /healthz и /readyz.
+// Liveness отвечает 200, пока процесс обрабатывает запросы, и не обращается
+// к зависимостям: их сбой не должен приводить к перезапуску.
+// Readiness падает, если не прошла хотя бы одна проверка, до окончания миграций
+// и во время остановки.
+type Health struct {
+	state   atomic.Int32
+	started time.Time
+	names   []string
+	checks  map[string]HealthCheck
+}
+
+type healthResponse struct {
+	Status string                 `json:"status"`
+	Reason string                 `json:"reason,omitempty"`
+	Uptime string                 `json:"uptime"`
+	Checks map[string]checkResult `json:"checks,omitempty"`
+}
+
+type checkResult struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+func NewHealth() *Health {
+	return &Health{
+		started: time.Now(),
+		checks:  make(map[string]HealthCheck),
+	}
+}
+
+// AddCheck регистрирует проверку зависимости для readiness
+func (h *Health) AddCheck(name string, check HealthCheck) {
+	h.names = append(h.names, name)
+	h.checks[name] = check
+}
+
+// SetReady вызывается, когда миграции применены и сервис готов принимать трафик
+func (h *Health) SetReady() {
+	h.state.CompareAndSwap(stateStarting, stateReady)
+}
+
+// SetShuttingDown переводит readiness в состояние отказа на время остановки
+func (h *Health) SetShuttingDown() {
+	h.state.Store(stateShuttingDown)
+}
+
+func (h *Health) HandleLiveness(w http.ResponseWriter, r *http.Request) {
+	resp := healthResponse{
+		Status: "ok",
+		Uptime: time.Since(h.started).Round(time.Second).String(),
+	}
+	if h.state.Load() == stateShuttingDown {
+		resp.Reason = "shutting down"
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Health) HandleReadiness(w http.ResponseWriter, r *http.Request) {
+	resp := healthResponse{
+		Status: "ok",
+		Uptime: time.Since(h.started).Round(time.Second).String(),
+		Checks: h.runChecks(r.Context()),
+	}
+
+	switch h.state.Load() {
+	case stateStarting:
+		resp.Status, resp.Reason = "unavailable", "starting"
+	case stateShuttingDown:
+		resp.Status, resp.Reason = "unavailable", "shutting down"
+	default:
+		for _, name := range h.names {
+			if resp.Checks[name].Status != "ok" {
+				resp.Status, resp.Reason = "unavailable", name+" check failed"
+				break
+			}
+		}
+	}
+
+	status := http.StatusOK
+	if resp.Status != "ok" {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, resp)
+}
+
+// runChecks выполняет все проверки параллельно, каждую со своим таймаутом
+func (h *Health) runChecks(ctx context.Context) map[string]checkResult {
+	results := make(map[string]checkResult, len(h.names))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, name := range h.names {
+		wg.Add(1)
+		go func(name string, check HealthCheck) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check(checkCtx)
+			result := checkResult{
+				Status:    "ok",
+				LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				result.Status = "fail"
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			results[name] = result
+			mu.Unlock()
+		}(name, h.checks[name])
+	}
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,97 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func readiness(t *testing.T, h *Health) (int, healthResponse) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.HandleReadiness(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var resp healthResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return rec.Code, resp
+}
+
+func TestReadiness_Lifecycle(t *testing.T) {
+	h := NewHealth()
+	h.AddCheck("postgres", func(ctx context.Context) error { return nil })
+
+	if code, resp := readiness(t, h); code != http.StatusServiceUnavailable || resp.Reason != "starting" {
+		t.Fatalf("before SetReady: got %d %q, want 503 starting", code, resp.Reason)
+	}
+
+	h.SetReady()
+	code, resp := readiness(t, h)
+	if code != http.StatusOK {
+		t.Fatalf("after SetReady: got %d, want 200", code)
+	}
+	if resp.Checks["postgres"].Status != "ok" {
+		t.Fatalf("postgres check = %+v, want ok", resp.Checks["postgres"])
+	}
+
+	h.SetShuttingDown()
+	if code, resp := readiness(t, h); code != http.StatusServiceUnavailable || resp.Reason != "shutting down" {
+		t.Fatalf("after SetShuttingDown: got %d %q, want 503 shutting down", code, resp.Reason)
+	}
+}
+
+func TestReadiness_FailedCheck(t *testing.T) {
+	h := NewHealth()
+	h.AddCheck("postgres", func(ctx context.Context) error { return nil })
+	h.AddCheck("minio", func(ctx context.Context) error { return errors.New("bucket missing") })
+	h.SetReady()
+
+	code, resp := readiness(t, h)
+	if code != http.StatusServiceUnavailable {
+		t.Fatalf("got %d, want 503", code)
+	}
+	if resp.Reason != "minio check failed" {
+		t.Fatalf("reason = %q", resp.Reason)
+	}
+	if got := resp.Checks["minio"]; got.Status != "fail" || got.Error != "bucket missing" {
+		t.Fatalf("minio check = %+v", got)
+	}
+}
+
+func TestLiveness_SkipsDependencyChecks(t *testing.T) {
+	h := NewHealth()
+	called := false
+	h.AddCheck("postgres", func(ctx context.Context) error {
+		called = true
+		return errors.New("connection refused")
+	})
+	h.SetReady()
+
+	liveness := func() (int, healthResponse) {
+		rec := httptest.NewRecorder()
+		h.HandleLiveness(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		var resp healthResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		return rec.Code, resp
+	}
+
+	code, resp := liveness()
+	if code != http.StatusOK || resp.Status != "ok" {
+		t.Fatalf("liveness: got %d %q, want 200 ok", code, resp.Status)
+	}
+	if called || len(resp.Checks) != 0 {
+		t.Fatalf("liveness must not run dependency checks, got %+v", resp.Checks)
+	}
+
+	h.SetShuttingDown()
+	if code, resp := liveness(); code != http.StatusOK || resp.Reason != "shutting down" {
+		t.Fatalf("after SetShuttingDown: got %d %q, want 200 shutting down", code, resp.Reason)
+	}
+}
@@ -1,44 +1,70 @@
 package transport
 
 import (
+	"context"
+	"errors"
 	"net/http"
 
+	"1337b04rd/internal/config"
 	"1337b04rd/internal/ports/left"
 	"1337b04rd/internal/ports/right"
 	"1337b04rd/pkg/logger"
 )
 
 type Server struct {
-	addr    string
 	router  *http.ServeMux
 	service left.APIPort
+	server  *http.Server
 }
 
-func NewHTTPServer(service left.APIPort, logger *logger.CustomLogger, imageUploader right.ImageStorage) *Server {
-	router := newRouter(service, logger, imageUploader)
+func NewHTTPServer(service left.APIPort, logger *logger.CustomLogger, imageUploader right.ImageStorage, health *Health, limiter *RateLimiter, cfg config.HTTPConfig, moderation config.ModerationConfig) *Server {
+	csrf := NewCSRF(cfg.CSRFSecret)
+	moderators := NewModeratorAuth(moderation, csrf, cfg.CookieSecure)
+	router := newRouter(service, logger, imageUploader, cfg, csrf, moderators, limiter)
 
-	addr := ":8080"
-	return &Server{
-		addr:    addr,
+	// Проверки здоровья не проходят через сессии, чтобы пробы не создавали пользователей
+	root := http.NewServeMux()
+	root.HandleFunc("GET /healthz", health.HandleLiveness)
+	root.HandleFunc("GET /readyz", health.HandleReadiness)
+	// Изображения отдаются без сессий: хотлинки не должны нагружать БД
+	root.Handle("GET /images/", router)
+	root.Handle("/", Chain(router,
+		WithSession(service, SessionCookie{Secure: cfg.CookieSecure}),
+		WithModerator(moderators),
+		CheckCSRF(csrf, cfg.MaxUploadSize),
+	))
+
+	s := &Server{
 		router:  router,
 		service: service,
 	}
+	s.server = &http.Server{
+		Addr:         cfg.Addr,
+		Handler:      root,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+	}
+	return s
 }
 
-func newRouter(service left.APIPort, logger *logger.CustomLogger, imageUploader right.ImageStorage) *http.ServeMux {
+func newRouter(service left.APIPort, logger *logger.CustomLogger, imageUploader right.ImageStorage, cfg config.HTTPConfig, csrf *CSRF, moderators *ModeratorAuth, limiter *RateLimiter) *http.ServeMux {
 	router := http.NewServeMux()
 
-	SetupRoutes(service, logger, imageUploader, router)
+	SetupRoutes(service, logger, imageUploader, cfg, csrf, moderators, limiter, router)
 	return router
 }
 
+// Serve блокируется до ошибки или до вызова Shutdown (в этом случае возвращает nil)
 func (s *Server) Serve() error {
-	wrappedRouter := Chain(s.router, WithSession(s.service))
-
-	server := &http.Server{
-		Addr:    s.addr,
-		Handler: wrappedRouter,
+	if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
 	}
+	return nil
+}
 
-	return server.ListenAndServe()
+// Shutdown перестаёт принимать новые соединения и ждёт завершения активных запросов
+// до дедлайна ctx.
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
 }
@@ -0,0 +1,85 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"1337b04rd/internal/domain"
+	"1337b04rd/internal/ports/right"
+)
+
+type fakeImageStorage struct {
+	right.ImageStorage
+	objects map[string][]byte
+}
+
+type nopReadSeekCloser struct {
+	*bytes.Reader
+}
+
+func (nopReadSeekCloser) Close() error { return nil }
+
+func (s *fakeImageStorage) GetImage(ctx context.Context, name string) (*right.ImageObject, error) {
+	data, ok := s.objects[name]
+	if !ok {
+		return nil, fmt.Errorf("image %s: %w", name, domain.ErrNotFound)
+	}
+	return &right.ImageObject{
+		Body:        nopReadSeekCloser{bytes.NewReader(data)},
+		Size:        int64(len(data)),
+		ContentType: "image/png",
+		ModTime:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		ETag:        `"abc"`,
+	}, nil
+}
+
+func serveImage(t *testing.T, h *Handler, path string, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	h.ServeImage(rec, req)
+	return rec
+}
+
+func TestServeImage(t *testing.T) {
+	h := &Handler{imageStorage: &fakeImageStorage{objects: map[string][]byte{"a.png": []byte("0123456789")}}}
+
+	rec := serveImage(t, h, "/images/a.png", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	for key, want := range map[string]string{
+		"Content-Length": "10",
+		"Content-Type":   "image/png",
+		"ETag":           `"abc"`,
+		"Cache-Control":  imageCacheControl,
+	} {
+		if got := rec.Header().Get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+
+	rec = serveImage(t, h, "/images/a.png", http.Header{"If-None-Match": {`"abc"`}})
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("conditional GET: status = %d, want 304", rec.Code)
+	}
+
+	rec = serveImage(t, h, "/images/a.png", http.Header{"Range": {"bytes=2-4"}})
+	body, _ := io.ReadAll(rec.Body)
+	if rec.Code != http.StatusPartialContent || string(body) != "234" {
+		t.Errorf("range: status = %d, body = %q, want 206 234", rec.Code, body)
+	}
+
+	if rec := serveImage(t, h, "/images/missing.png", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("missing: status = %d, want 404", rec.Code)
+	}
+}
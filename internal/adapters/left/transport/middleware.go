@@ -2,9 +2,9 @@ package transport
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
-	"time"
 
 	"1337b04rd/internal/domain"
 	"1337b04rd/internal/ports/left"
@@ -16,6 +16,63 @@ const (
 	SessionKey ContextKey = "session"
 )
 
+const (
+	sessionCookieName = "session_id"
+	// secureSessionCookieName: браузер примет cookie с префиксом __Host- только
+	// с флагом Secure, Path=/ и без Domain, поэтому поддомены не могут её подменить
+	secureSessionCookieName = "__Host-" + sessionCookieName
+)
+
+// SessionCookie — параметры cookie сессии
+type SessionCookie struct {
+	// Secure — отдавать cookie только по HTTPS и с префиксом __Host-
+	Secure bool
+}
+
+func (c SessionCookie) name() string {
+	if c.Secure {
+		return secureSessionCookieName
+	}
+	return sessionCookieName
+}
+
+// read возвращает идентификатор сессии. При включённом Secure принимается и cookie
+// без префикса, выданная до включения: legacy сообщает, что её пора заменить.
+func (c SessionCookie) read(r *http.Request) (id string, legacy bool) {
+	if cookie, err := r.Cookie(c.name()); err == nil && cookie.Value != "" {
+		return cookie.Value, false
+	}
+	if c.Secure {
+		if cookie, err := r.Cookie(sessionCookieName); err == nil && cookie.Value != "" {
+			return cookie.Value, true
+		}
+	}
+	return "", false
+}
+
+func (c SessionCookie) set(w http.ResponseWriter, session *domain.Session) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     c.name(),
+		Value:    session.ID,
+		Path:     "/",
+		Expires:  session.ExpiresAt,
+		HttpOnly: true,
+		Secure:   c.Secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// dropLegacy удаляет cookie без префикса после выдачи cookie с префиксом
+func (c SessionCookie) dropLegacy(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+}
+
 type Middleware func(http.Handler) http.Handler
 
 func Chain(h http.Handler, middlewares ...Middleware) http.Handler {
@@ -25,36 +82,72 @@ func Chain(h http.Handler, middlewares ...Middleware) http.Handler {
 	return h
 }
 
-func WithSession(sessionService left.SessionPort) Middleware {
+// WithSession находит сессию по cookie и продлевает её. Новых пользователей WithSession
+// не создаёт: анонимные читатели, боты и хотлинки обслуживаются без сессии.
+// Маршрутам, которым нужен пользователь, нужен ещё и RequireSession.
+func WithSession(sessionService left.SessionPort, cookie SessionCookie) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			var session *domain.Session
-			var err error
+			sessionID, legacy := cookie.read(r)
+			if sessionID == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
 
-			cookie, err := r.Cookie("session_id")
-			if err == nil && cookie.Value != "" {
-				session, err = sessionService.GetSessionByID(context.Background(), cookie.Value)
+			session, err := sessionService.GetSessionByID(r.Context(), sessionID)
+			if err != nil {
+				if !errors.Is(err, domain.ErrNotFound) {
+					slog.Error("Failed to load session", "error", err)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !session.IsActive {
+				next.ServeHTTP(w, r)
+				return
 			}
 
-			if err != nil || session == nil || !session.IsActive {
-				session, err = sessionService.CreateSession(context.Background())
-				if err != nil {
-					http.Error(w, "failed to create session", http.StatusInternalServerError)
-					slog.Error("Failed to create session: " + err.Error())
-					return
+			renewed, err := sessionService.RenewSession(r.Context(), session)
+			switch {
+			case errors.Is(err, domain.ErrNotFound):
+				// Сессия истекла или удалена между чтением и продлением
+				next.ServeHTTP(w, r)
+				return
+			case err != nil:
+				// Сессия ещё действует, продлить можно при следующем запросе
+				slog.Error("Failed to renew session", "error", err)
+			case renewed || legacy:
+				cookie.set(w, session)
+				if legacy {
+					cookie.dropLegacy(w)
 				}
+			}
+
+			ctx := context.WithValue(r.Context(), SessionKey, session)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequireSession создаёт пользователя и сессию, если WithSession не нашёл действующей.
+// Ставится только на маршруты, где пользователь пишет или собирается писать.
+func RequireSession(sessionService left.SessionPort, cookie SessionCookie) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if session, ok := r.Context().Value(SessionKey).(*domain.Session); ok && session != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
 
-				http.SetCookie(w, &http.Cookie{
-					Name:     "session_id",
-					Value:    session.ID,
-					Path:     "/",
-					Expires:  time.Now().Add(7 * 24 * time.Hour),
-					HttpOnly: true,
-					SameSite: http.SameSiteLaxMode,
-				})
+			session, err := sessionService.CreateSession(r.Context())
+			if err != nil {
+				http.Error(w, "failed to create session", http.StatusInternalServerError)
+				slog.Error("Failed to create session: " + err.Error())
+				return
 			}
+			cookie.set(w, session)
 
-			ctx := context.WithValue(context.Background(), SessionKey, session)
+			ctx := context.WithValue(r.Context(), SessionKey, session)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
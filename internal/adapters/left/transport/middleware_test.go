@@ -0,0 +1,154 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"1337b04rd/internal/domain"
+	"1337b04rd/internal/ports/left"
+)
+
+type fakeSessions struct {
+	left.SessionPort
+	session *domain.Session
+	renew   bool
+	created int
+}
+
+func (s *fakeSessions) GetSessionByID(ctx context.Context, sessionID string) (*domain.Session, error) {
+	if s.session == nil || s.session.ID != sessionID {
+		return nil, domain.ErrNotFound
+	}
+	session := *s.session
+	return &session, nil
+}
+
+func (s *fakeSessions) RenewSession(ctx context.Context, session *domain.Session) (bool, error) {
+	if !s.renew {
+		return false, nil
+	}
+	session.ExpiresAt = session.ExpiresAt.Add(time.Hour)
+	return true, nil
+}
+
+func (s *fakeSessions) CreateSession(ctx context.Context) (*domain.Session, error) {
+	s.created++
+	return &domain.Session{ID: "new", UserID: "u2", ExpiresAt: time.Now().Add(time.Hour), IsActive: true}, nil
+}
+
+func TestWithSession(t *testing.T) {
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	active := &domain.Session{ID: "s1", UserID: "u1", ExpiresAt: expires, IsActive: true}
+
+	tests := []struct {
+		name        string
+		stored      *domain.Session
+		renew       bool
+		wantUser    string
+		wantCookie  bool
+		wantExpires time.Time
+	}{
+		{name: "active", stored: active, wantUser: "u1"},
+		{name: "renewed", stored: active, renew: true, wantUser: "u1", wantCookie: true, wantExpires: expires.Add(time.Hour)},
+		{name: "unknown"},
+		{name: "expired", stored: &domain.Session{ID: "s1", UserID: "u1", ExpiresAt: expires}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessions := &fakeSessions{session: tt.stored, renew: tt.renew}
+			var got *domain.Session
+			handler := WithSession(sessions, SessionCookie{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got, _ = r.Context().Value(SessionKey).(*domain.Session)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/catalog", nil)
+			req.AddCookie(&http.Cookie{Name: "session_id", Value: "s1"})
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if tt.wantUser == "" {
+				if got != nil {
+					t.Fatalf("session = %+v, want none", got)
+				}
+			} else if got == nil || got.UserID != tt.wantUser {
+				t.Fatalf("session = %+v, want user %s", got, tt.wantUser)
+			}
+			if sessions.created != 0 {
+				t.Errorf("WithSession created %d sessions", sessions.created)
+			}
+			cookies := rec.Result().Cookies()
+			if !tt.wantCookie {
+				if len(cookies) != 0 {
+					t.Errorf("unexpected cookies %v", cookies)
+				}
+				return
+			}
+			if len(cookies) != 1 || cookies[0].Value != got.ID {
+				t.Fatalf("cookies = %v, want session %s", cookies, got.ID)
+			}
+			if !tt.wantExpires.IsZero() && !cookies[0].Expires.Equal(tt.wantExpires) {
+				t.Errorf("cookie expires %v, want %v", cookies[0].Expires, tt.wantExpires)
+			}
+		})
+	}
+}
+
+func TestRequireSession(t *testing.T) {
+	sessions := &fakeSessions{session: &domain.Session{ID: "s1", UserID: "u1", ExpiresAt: time.Now().Add(time.Hour), IsActive: true}}
+	var got *domain.Session
+	handler := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = r.Context().Value(SessionKey).(*domain.Session)
+	}), WithSession(sessions, SessionCookie{}), RequireSession(sessions, SessionCookie{}))
+
+	// С действующей сессией пользователь не создаётся
+	req := httptest.NewRequest(http.MethodGet, "/create-post", nil)
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: "s1"})
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if got == nil || got.UserID != "u1" || sessions.created != 0 {
+		t.Fatalf("session = %+v, created = %d; want existing session", got, sessions.created)
+	}
+
+	// Без cookie создаётся новый пользователь, и cookie выставляется
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/create-post", nil))
+	if got == nil || got.UserID != "u2" || sessions.created != 1 {
+		t.Fatalf("session = %+v, created = %d; want a new session", got, sessions.created)
+	}
+	if cookies := rec.Result().Cookies(); len(cookies) != 1 || cookies[0].Value != "new" {
+		t.Fatalf("cookies = %v, want the new session", cookies)
+	}
+}
+
+func TestWithSession_SecureCookie(t *testing.T) {
+	sessions := &fakeSessions{session: &domain.Session{ID: "s1", UserID: "u1", ExpiresAt: time.Now().Add(time.Hour), IsActive: true}}
+	cookie := SessionCookie{Secure: true}
+	var got *domain.Session
+	handler := WithSession(sessions, cookie)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = r.Context().Value(SessionKey).(*domain.Session)
+	}))
+
+	// Cookie, выданная до включения Secure, заменяется на cookie с префиксом
+	req := httptest.NewRequest(http.MethodGet, "/catalog", nil)
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: "s1"})
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got == nil || got.UserID != "u1" {
+		t.Fatalf("session = %+v, want legacy session", got)
+	}
+	cookies := map[string]*http.Cookie{}
+	for _, c := range rec.Result().Cookies() {
+		cookies[c.Name] = c
+	}
+	secure, ok := cookies["__Host-session_id"]
+	if !ok || secure.Value != "s1" || !secure.Secure || !secure.HttpOnly || secure.Path != "/" || secure.Domain != "" {
+		t.Errorf("secure cookie = %+v", secure)
+	}
+	if legacy, ok := cookies["session_id"]; !ok || legacy.MaxAge >= 0 {
+		t.Errorf("legacy cookie is not dropped: %+v", legacy)
+	}
+}
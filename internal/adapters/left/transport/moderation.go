@@ -0,0 +1,332 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"1337b04rd/internal/config"
+	"1337b04rd/internal/domain"
+)
+
+const (
+	ModeratorKey ContextKey = "moderator"
+
+	moderatorCookieName       = "mod_session"
+	secureModeratorCookieName = "__Host-" + moderatorCookieName
+	moderationLogLimit        = 200
+)
+
+// ModeratorAuth проверяет учётные данные модератора и выдаёт подписанную cookie входа.
+// Вход модератора не связан с анонимной сессией: cookie содержит только срок действия
+// и HMAC, поэтому её не нужно хранить, а смена пароля завершает все входы.
+// Cookie выдаётся с SameSite=Strict, так что чужие сайты не могут отправить
+// от имени модератора даже формы, которые прошли бы проверку Origin.
+type ModeratorAuth struct {
+	username string
+	password string
+	key      []byte
+	ttl      time.Duration
+	secure   bool
+	now      func() time.Time
+}
+
+// NewModeratorAuth возвращает nil, если модерация не настроена.
+// Ключ подписи выводится из ключа CSRF и пароля модератора.
+func NewModeratorAuth(cfg config.ModerationConfig, csrf *CSRF, secure bool) *ModeratorAuth {
+	if !cfg.Enabled() {
+		return nil
+	}
+	mac := hmac.New(sha256.New, csrf.secret)
+	mac.Write([]byte("moderator:" + cfg.Username + ":" + cfg.Password))
+	return &ModeratorAuth{
+		username: cfg.Username,
+		password: cfg.Password,
+		key:      mac.Sum(nil),
+		ttl:      cfg.SessionTTL,
+		secure:   secure,
+		now:      time.Now,
+	}
+}
+
+// Check сравнивает учётные данные за постоянное время
+func (a *ModeratorAuth) Check(username, password string) bool {
+	userSum := sha256.Sum256([]byte(username))
+	wantUser := sha256.Sum256([]byte(a.username))
+	passSum := sha256.Sum256([]byte(password))
+	wantPass := sha256.Sum256([]byte(a.password))
+	userOK := subtle.ConstantTimeCompare(userSum[:], wantUser[:])
+	passOK := subtle.ConstantTimeCompare(passSum[:], wantPass[:])
+	return userOK&passOK == 1
+}
+
+func (a *ModeratorAuth) cookieName() string {
+	if a.secure {
+		return secureModeratorCookieName
+	}
+	return moderatorCookieName
+}
+
+func (a *ModeratorAuth) sign(expires string) string {
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// issue выдаёт cookie вида <unix-время истечения>.<подпись>
+func (a *ModeratorAuth) issue(w http.ResponseWriter) {
+	expiresAt := a.now().Add(a.ttl)
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+	http.SetCookie(w, &http.Cookie{
+		Name:     a.cookieName(),
+		Value:    expires + "." + a.sign(expires),
+		Path:     "/",
+		Expires:  expiresAt,
+		HttpOnly: true,
+		Secure:   a.secure,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+func (a *ModeratorAuth) clear(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     a.cookieName(),
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   a.secure,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// moderator возвращает имя модератора, если запрос несёт действующую cookie входа
+func (a *ModeratorAuth) moderator(r *http.Request) (string, bool) {
+	cookie, err := r.Cookie(a.cookieName())
+	if err != nil {
+		return "", false
+	}
+	expires, signature, ok := strings.Cut(cookie.Value, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(a.sign(expires))) {
+		return "", false
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || !a.now().Before(time.Unix(unix, 0)) {
+		return "", false
+	}
+	return a.username, true
+}
+
+// WithModerator кладёт в контекст имя вошедшего модератора. Если модерация
+// не настроена (auth == nil), запросы проходят без изменений.
+func WithModerator(auth *ModeratorAuth) Middleware {
+	return func(next http.Handler) http.Handler {
+		if auth == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			name, ok := auth.moderator(r)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx := context.WithValue(r.Context(), ModeratorKey, name)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequireModerator пускает только вошедших модераторов. Страницы перенаправляют
+// на форму входа, действия отклоняются с 403.
+func RequireModerator(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := moderatorName(r); ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if r.Method == http.MethodGet {
+			http.Redirect(w, r, "/mod/login", http.StatusSeeOther)
+			return
+		}
+		http.Error(w, "Forbidden", http.StatusForbidden)
+	})
+}
+
+func moderatorName(r *http.Request) (string, bool) {
+	name, ok := r.Context().Value(ModeratorKey).(string)
+	return name, ok && name != ""
+}
+
+type modLoginPage struct {
+	Username  string
+	Error     string
+	CSRFToken string
+}
+
+type modLogPage struct {
+	Moderator string
+	Entries   []domain.ModerationLogEntry
+	CSRFToken string
+}
+
+func (h *Handler) HandleModLoginForm(w http.ResponseWriter, r *http.Request) {
+	if _, ok := moderatorName(r); ok {
+		http.Redirect(w, r, "/mod", http.StatusSeeOther)
+		return
+	}
+	h.renderPage(w, http.StatusOK, "mod-login.html", modLoginPage{CSRFToken: h.csrfToken(r)})
+}
+
+func (h *Handler) HandleModLogin(w http.ResponseWriter, r *http.Request) {
+	if err := h.parseForm(r); err != nil {
+		var reqErr *requestError
+		errors.As(err, &reqErr)
+		http.Error(w, reqErr.Message, reqErr.Status)
+		return
+	}
+
+	username := r.FormValue("username")
+	if !h.moderators.Check(username, r.FormValue("password")) {
+		slog.Warn("Failed moderator login", "username", username)
+		h.renderPage(w, http.StatusUnauthorized, "mod-login.html", modLoginPage{
+			Username:  username,
+			Error:     "Invalid username or password",
+			CSRFToken: h.csrfToken(r),
+		})
+		return
+	}
+
+	h.moderators.issue(w)
+	slog.Info("Moderator logged in", "moderator", username)
+	http.Redirect(w, r, "/mod", http.StatusSeeOther)
+}
+
+func (h *Handler) HandleModLogout(w http.ResponseWriter, r *http.Request) {
+	h.moderators.clear(w)
+	http.Redirect(w, r, "/catalog", http.StatusSeeOther)
+}
+
+// HandleModLog показывает последние действия модераторов
+func (h *Handler) HandleModLog(w http.ResponseWriter, r *http.Request) {
+	moderator, _ := moderatorName(r)
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	entries, err := h.service.ModerationLog(ctx, moderationLogLimit)
+	if err != nil {
+		slog.Error("ModerationLog error", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	h.renderPage(w, http.StatusOK, "mod-log.html", modLogPage{Moderator: moderator, Entries: entries, CSRFToken: h.csrfToken(r)})
+}
+
+func (h *Handler) HandleModDeletePost(w http.ResponseWriter, r *http.Request) {
+	h.moderate(w, r, "/catalog", func(ctx context.Context, moderator string) error {
+		return h.service.DeletePost(ctx, moderator, r.PathValue("id"))
+	})
+}
+
+func (h *Handler) HandleModRemovePostImages(w http.ResponseWriter, r *http.Request) {
+	h.moderate(w, r, "/post/"+r.PathValue("id"), func(ctx context.Context, moderator string) error {
+		return h.service.RemovePostImages(ctx, moderator, r.PathValue("id"))
+	})
+}
+
+// HandleModLockPost закрывает тред, если locked=true, и открывает в остальных случаях
+func (h *Handler) HandleModLockPost(w http.ResponseWriter, r *http.Request) {
+	h.moderate(w, r, "/post/"+r.PathValue("id"), func(ctx context.Context, moderator string) error {
+		return h.service.LockPost(ctx, moderator, r.PathValue("id"), r.FormValue("locked") == "true")
+	})
+}
+
+// HandleModPinPost закрепляет тред, если pinned=true, и открепляет в остальных случаях
+func (h *Handler) HandleModPinPost(w http.ResponseWriter, r *http.Request) {
+	h.moderate(w, r, "/post/"+r.PathValue("id"), func(ctx context.Context, moderator string) error {
+		return h.service.PinPost(ctx, moderator, r.PathValue("id"), r.FormValue("pinned") == "true")
+	})
+}
+
+// HandleModComment выполняет action (delete или remove-images) над комментарием comment_id.
+// Номер комментария передаётся полем формы, чтобы панель модератора работала без JavaScript.
+func (h *Handler) HandleModComment(w http.ResponseWriter, r *http.Request) {
+	h.moderate(w, r, "/catalog", func(ctx context.Context, moderator string) error {
+		commentID := strings.TrimSpace(r.FormValue("comment_id"))
+		if commentID == "" {
+			return &requestError{Status: http.StatusBadRequest, Message: "comment_id is required"}
+		}
+		switch r.FormValue("action") {
+		case "delete":
+			return h.service.DeleteComment(ctx, moderator, commentID)
+		case "remove-images":
+			return h.service.RemoveCommentImages(ctx, moderator, commentID)
+		default:
+			return &requestError{Status: http.StatusBadRequest, Message: "unknown action"}
+		}
+	})
+}
+
+// moderate разбирает форму, выполняет действие от имени модератора и перенаправляет
+// по полю return, а если его нет — на fallback
+func (h *Handler) moderate(w http.ResponseWriter, r *http.Request, fallback string, action func(ctx context.Context, moderator string) error) {
+	if err := h.parseForm(r); err != nil {
+		var reqErr *requestError
+		errors.As(err, &reqErr)
+		http.Error(w, reqErr.Message, reqErr.Status)
+		return
+	}
+	moderator, ok := moderatorName(r)
+	if !ok {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	if err := action(ctx, moderator); err != nil {
+		status, body := errorStatus(err)
+		if status == http.StatusInternalServerError {
+			slog.Error("Moderation action failed", "moderator", moderator, "path", r.URL.Path, "error", err)
+		}
+		http.Error(w, body.Message, status)
+		return
+	}
+
+	http.Redirect(w, r, returnPath(r, fallback), http.StatusSeeOther)
+}
+
+// returnPath берёт адрес возврата из поля return, принимая только пути этого сайта
+func returnPath(r *http.Request, fallback string) string {
+	path := r.FormValue("return")
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "/\\") {
+		return fallback
+	}
+	return path
+}
+
+func (h *Handler) renderPage(w http.ResponseWriter, status int, name string, data any) {
+	var buf bytes.Buffer
+	if err := h.templates.ExecuteTemplate(&buf, name, data); err != nil {
+		slog.Error("Failed to render template", "template", name, "error", err)
+		http.Error(w, "Render error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		slog.Error("Failed to send response", "error", err)
+	}
+}
@@ -0,0 +1,173 @@
+package transport
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"1337b04rd/internal/config"
+	"1337b04rd/internal/domain"
+)
+
+type moderationCall struct {
+	moderator, action, target string
+	flag                      bool
+}
+
+type fakeModeration struct {
+	fakeService
+	calls []moderationCall
+}
+
+func (s *fakeModeration) LockPost(ctx context.Context, moderator, postID string, locked bool) error {
+	if postID == "missing" {
+		return domain.ErrNotFound
+	}
+	s.calls = append(s.calls, moderationCall{moderator: moderator, action: "lock", target: postID, flag: locked})
+	return nil
+}
+
+func (s *fakeModeration) DeleteComment(ctx context.Context, moderator, commentID string) error {
+	s.calls = append(s.calls, moderationCall{moderator: moderator, action: "delete-comment", target: commentID})
+	return nil
+}
+
+func newModeratorAuth(t *testing.T) *ModeratorAuth {
+	t.Helper()
+	auth := NewModeratorAuth(config.ModerationConfig{
+		Username:   "janitor",
+		Password:   "correct horse battery staple",
+		SessionTTL: time.Hour,
+	}, NewCSRF(strings.Repeat("k", 32)), false)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	auth.now = func() time.Time { return now }
+	return auth
+}
+
+// newModerationMux повторяет цепочку NewHTTPServer для маршрутов модерации
+func newModerationMux(service *fakeModeration, auth *ModeratorAuth) http.Handler {
+	h := &Handler{
+		service:        service,
+		requestTimeout: 5 * time.Second,
+		maxUploadSize:  1 << 20,
+		moderators:     auth,
+		templates:      template.Must(template.New("mod-login.html").Parse(`{{.Error}}`)),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /mod/login", h.HandleModLogin)
+	mux.Handle("POST /mod/post/{id}/lock", RequireModerator(http.HandlerFunc(h.HandleModLockPost)))
+	mux.Handle("POST /mod/comment", RequireModerator(http.HandlerFunc(h.HandleModComment)))
+	return WithModerator(auth)(mux)
+}
+
+func postForm(handler http.Handler, path string, form url.Values, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestModeratorLogin(t *testing.T) {
+	auth := newModeratorAuth(t)
+	service := &fakeModeration{}
+	handler := newModerationMux(service, auth)
+
+	rec := postForm(handler, "/mod/login", url.Values{"username": {"janitor"}, "password": {"wrong"}})
+	if rec.Code != http.StatusUnauthorized || len(rec.Result().Cookies()) != 0 {
+		t.Fatalf("wrong password: status %d, cookies %v", rec.Code, rec.Result().Cookies())
+	}
+
+	rec = postForm(handler, "/mod/login", url.Values{"username": {"janitor"}, "password": {"correct horse battery staple"}})
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("login: status %d, body %s", rec.Code, rec.Body)
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != moderatorCookieName || cookies[0].SameSite != http.SameSiteStrictMode || !cookies[0].HttpOnly {
+		t.Fatalf("cookies = %+v", cookies)
+	}
+	modCookie := cookies[0]
+
+	rec = postForm(handler, "/mod/post/p1/lock", url.Values{"locked": {"true"}, "return": {"/post/p1"}}, modCookie)
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/post/p1" {
+		t.Fatalf("lock: status %d, location %q", rec.Code, rec.Header().Get("Location"))
+	}
+	if len(service.calls) != 1 || service.calls[0] != (moderationCall{moderator: "janitor", action: "lock", target: "p1", flag: true}) {
+		t.Fatalf("calls = %+v", service.calls)
+	}
+
+	rec = postForm(handler, "/mod/post/missing/lock", url.Values{"locked": {"true"}}, modCookie)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("lock of missing post: status %d, want 404", rec.Code)
+	}
+
+	rec = postForm(handler, "/mod/comment", url.Values{"comment_id": {"c1"}, "action": {"delete"}, "return": {"//evil.example"}}, modCookie)
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/catalog" {
+		t.Errorf("delete comment: status %d, location %q", rec.Code, rec.Header().Get("Location"))
+	}
+	rec = postForm(handler, "/mod/comment", url.Values{"comment_id": {"c1"}, "action": {"purge"}}, modCookie)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown action: status %d, want 400", rec.Code)
+	}
+}
+
+func TestRequireModerator_RejectsForgedCookies(t *testing.T) {
+	auth := newModeratorAuth(t)
+	service := &fakeModeration{}
+	handler := newModerationMux(service, auth)
+
+	rec := httptest.NewRecorder()
+	auth.issue(rec)
+	valid := rec.Result().Cookies()[0]
+
+	expires, signature, _ := strings.Cut(valid.Value, ".")
+	later := strconv.FormatInt(time.Now().Add(24*time.Hour).Unix(), 10)
+	other := newModeratorAuth(t)
+	other.key = []byte("another key")
+
+	cookies := map[string]*http.Cookie{
+		"no cookie":      nil,
+		"bad signature":  {Name: moderatorCookieName, Value: expires + ".AAAA"},
+		"moved expiry":   {Name: moderatorCookieName, Value: later + "." + signature},
+		"other key":      {Name: moderatorCookieName, Value: expires + "." + other.sign(expires)},
+		"malformed":      {Name: moderatorCookieName, Value: "garbage"},
+		"anonymous only": {Name: sessionCookieName, Value: "s1"},
+	}
+	for name, cookie := range cookies {
+		t.Run(name, func(t *testing.T) {
+			var rec *httptest.ResponseRecorder
+			if cookie == nil {
+				rec = postForm(handler, "/mod/post/p1/lock", url.Values{"locked": {"true"}})
+			} else {
+				rec = postForm(handler, "/mod/post/p1/lock", url.Values{"locked": {"true"}}, cookie)
+			}
+			if rec.Code != http.StatusForbidden {
+				t.Errorf("status = %d, want 403", rec.Code)
+			}
+		})
+	}
+
+	// Cookie перестаёт действовать по истечении срока
+	auth.now = func() time.Time { return time.Date(2025, 1, 1, 13, 0, 1, 0, time.UTC) }
+	if rec := postForm(handler, "/mod/post/p1/lock", url.Values{"locked": {"true"}}, valid); rec.Code != http.StatusForbidden {
+		t.Errorf("expired cookie: status = %d, want 403", rec.Code)
+	}
+	if len(service.calls) != 0 {
+		t.Errorf("rejected requests reached the service: %+v", service.calls)
+	}
+}
+
+func TestNewModeratorAuth_Disabled(t *testing.T) {
+	if auth := NewModeratorAuth(config.ModerationConfig{Username: "janitor"}, NewCSRF(""), false); auth != nil {
+		t.Fatal("moderation is enabled without a password")
+	}
+}
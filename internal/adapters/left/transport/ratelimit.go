@@ -0,0 +1,199 @@
+package transport
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"1337b04rd/internal/domain"
+	"1337b04rd/internal/ports/right"
+)
+
+// RateLimits — лимиты по видам действий; нулевой лимит отключает проверку
+type RateLimits struct {
+	Posts    domain.RateLimit
+	Comments domain.RateLimit
+	// Images считается по числу загружаемых изображений, а не запросов
+	Images domain.RateLimit
+	// Logins — попытки входа модератора
+	Logins domain.RateLimit
+}
+
+// RateLimiter ограничивает частоту действий отдельно для пользователя сессии
+// и для IP-адреса клиента, чтобы лимит не обходился ни сменой сессии, ни сменой адреса.
+type RateLimiter struct {
+	store          right.RateLimitStore
+	limits         RateLimits
+	trustedProxies []*net.IPNet
+	maxBodySize    int64
+	now            func() time.Time
+}
+
+// NewRateLimiter создаёт ограничитель. trustedProxies — сети обратных прокси,
+// которым можно верить в X-Forwarded-For; maxBodySize ограничивает разбор формы
+// при подсчёте изображений.
+func NewRateLimiter(store right.RateLimitStore, limits RateLimits, trustedProxies []*net.IPNet, maxBodySize int64) *RateLimiter {
+	return &RateLimiter{
+		store:          store,
+		limits:         limits,
+		trustedProxies: trustedProxies,
+		maxBodySize:    maxBodySize,
+		now:            time.Now,
+	}
+}
+
+// Posts ограничивает создание тредов
+func (l *RateLimiter) Posts() Middleware {
+	return l.middleware("post", l.limits.Posts)
+}
+
+// Comments ограничивает комментарии и ответы
+func (l *RateLimiter) Comments() Middleware {
+	return l.middleware("comment", l.limits.Comments)
+}
+
+// Logins ограничивает подбор пароля модератора
+func (l *RateLimiter) Logins() Middleware {
+	return l.middleware("login", l.limits.Logins)
+}
+
+// StartPruning каждые interval удаляет вёдра, которые успели наполниться.
+// Останавливается вместе с ctx.
+func (l *RateLimiter) StartPruning(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := l.store.Prune(ctx, l.now().Add(-l.longestInterval())); err != nil {
+					slog.Error("Failed to prune rate limit buckets", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// longestInterval — за это время наполняется любое ведро, и его можно забыть
+func (l *RateLimiter) longestInterval() time.Duration {
+	return max(l.limits.Posts.Interval, l.limits.Comments.Interval, l.limits.Images.Interval, l.limits.Logins.Interval)
+}
+
+func (l *RateLimiter) middleware(action string, limit domain.RateLimit) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			images := 0
+			if l.limits.Images.Enabled() && isMultipart(r) {
+				if err := parseForm(r, l.maxBodySize); err != nil {
+					// Ошибку разбора сообщит обработчик
+					next.ServeHTTP(w, r)
+					return
+				}
+				images = len(formImages(r.MultipartForm))
+			}
+
+			checks := []struct {
+				action string
+				limit  domain.RateLimit
+				cost   int
+			}{
+				{action: action, limit: limit, cost: 1},
+				{action: "image", limit: l.limits.Images, cost: images},
+			}
+
+			// Все вёдра проверяются вместе: отказ одного не тратит токены остальных
+			keys := l.keys(r)
+			var takes []domain.RateLimitTake
+			for _, check := range checks {
+				if !check.limit.Enabled() || check.cost == 0 {
+					continue
+				}
+				for _, key := range keys {
+					takes = append(takes, domain.RateLimitTake{Key: check.action + ":" + key, Limit: check.limit, Cost: check.cost})
+				}
+			}
+
+			if len(takes) > 0 {
+				allowed, retryAfter, err := l.store.Take(r.Context(), takes, l.now())
+				if err != nil {
+					// Недоступное хранилище лимитов не должно останавливать борду
+					slog.Error("Rate limit check failed", "error", err)
+				} else if !allowed {
+					tooManyRequests(w, r, retryAfter)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// keys возвращает ключи вёдер: пользователь сессии, если она есть, и IP клиента
+func (l *RateLimiter) keys(r *http.Request) []string {
+	keys := make([]string, 0, 2)
+	if session, ok := r.Context().Value(SessionKey).(*domain.Session); ok && session != nil {
+		keys = append(keys, "user:"+session.UserID)
+	}
+	if ip := clientIP(r, l.trustedProxies); ip != "" {
+		keys = append(keys, "ip:"+ip)
+	}
+	return keys
+}
+
+// clientIP берёт адрес соединения, а если он принадлежит доверенному прокси —
+// ближайший к серверу недоверенный адрес из X-Forwarded-For. Адреса левее него
+// клиент мог подставить сам.
+func clientIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return host
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0 && isTrusted(ip, trustedProxies); i-- {
+		next := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if next == nil {
+			break
+		}
+		ip = next
+	}
+	return ip.String()
+}
+
+func isTrusted(ip net.IP, trustedProxies []*net.IPNet) bool {
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func isMultipart(r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType == "multipart/form-data"
+}
+
+func tooManyRequests(w http.ResponseWriter, r *http.Request, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+	if strings.HasPrefix(r.URL.Path, "/api/") {
+		writeJSONError(w, &requestError{Status: http.StatusTooManyRequests, Message: "Too many requests, try again later"})
+		return
+	}
+	http.Error(w, "Too many requests, try again later", http.StatusTooManyRequests)
+}
@@ -0,0 +1,165 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"1337b04rd/internal/adapters/right/ratelimit"
+	"1337b04rd/internal/domain"
+)
+
+func newTestLimiter(limits RateLimits, trusted ...string) *RateLimiter {
+	var networks []*net.IPNet
+	for _, cidr := range trusted {
+		_, network, _ := net.ParseCIDR(cidr)
+		networks = append(networks, network)
+	}
+	l := NewRateLimiter(ratelimit.NewMemoryStore(), limits, networks, 1<<20)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+	return l
+}
+
+func TestRateLimiter_Posts(t *testing.T) {
+	l := newTestLimiter(RateLimits{Posts: domain.RateLimit{Burst: 2, Interval: time.Minute}})
+	handler := l.Posts()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	post := func(userID, remoteAddr, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.RemoteAddr = remoteAddr
+		if userID != "" {
+			req = req.WithContext(context.WithValue(req.Context(), SessionKey, &domain.Session{ID: "s-" + userID, UserID: userID}))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i := 0; i < 2; i++ {
+		if rec := post("u1", "203.0.113.1:1000", "/submit-post"); rec.Code != http.StatusOK {
+			t.Fatalf("post %d: status = %d", i, rec.Code)
+		}
+	}
+
+	rec := post("u1", "203.0.113.1:1000", "/submit-post")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "30" {
+		t.Fatalf("status = %d, Retry-After = %q; want 429 and 30", rec.Code, rec.Header().Get("Retry-After"))
+	}
+
+	// Смена адреса не помогает той же сессии, смена сессии — тому же адресу
+	if rec := post("u1", "198.51.100.7:1000", "/submit-post"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("same user from another IP: status = %d", rec.Code)
+	}
+	if rec := post("u2", "203.0.113.1:1000", "/submit-post"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("another user from the same IP: status = %d", rec.Code)
+	}
+
+	rec = post("", "203.0.113.1:1000", "/api/v1/posts")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Content-Type") != "application/json; charset=utf-8" {
+		t.Errorf("api: status = %d, content type = %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+}
+
+func TestRateLimiter_RejectedRequestKeepsOtherBuckets(t *testing.T) {
+	l := newTestLimiter(RateLimits{Posts: domain.RateLimit{Burst: 2, Interval: time.Minute}})
+	handler := l.Posts()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	post := func(userID, remoteAddr string) int {
+		req := httptest.NewRequest(http.MethodPost, "/submit-post", nil)
+		req.RemoteAddr = remoteAddr
+		req = req.WithContext(context.WithValue(req.Context(), SessionKey, &domain.Session{ID: "s-" + userID, UserID: userID}))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// u2 исчерпывает лимит адреса
+	for i := 0; i < 2; i++ {
+		if code := post("u2", "203.0.113.1:1000"); code != http.StatusOK {
+			t.Fatalf("u2 post %d: status = %d", i, code)
+		}
+	}
+
+	// Отказы по адресу не должны тратить лимит пользователя u1
+	for i := 0; i < 3; i++ {
+		if code := post("u1", "203.0.113.1:1000"); code != http.StatusTooManyRequests {
+			t.Fatalf("u1 from exhausted IP: status = %d, want 429", code)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if code := post("u1", "198.51.100.7:1000"); code != http.StatusOK {
+			t.Fatalf("u1 post %d from another IP: status = %d, want full quota", i, code)
+		}
+	}
+}
+
+func TestRateLimiter_CountsImages(t *testing.T) {
+	l := newTestLimiter(RateLimits{Images: domain.RateLimit{Burst: 3, Interval: time.Minute}})
+	handler := l.Comments()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	upload := func(images int) int {
+		var body bytes.Buffer
+		w := multipart.NewWriter(&body)
+		w.WriteField("content", "hi")
+		for i := 0; i < images; i++ {
+			part, _ := w.CreateFormFile("images", fmt.Sprintf("%d.png", i))
+			part.Write([]byte("png"))
+		}
+		w.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/post/submit-comment", &body)
+		req.Header.Set("Content-Type", w.FormDataContentType())
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := upload(2); code != http.StatusOK {
+		t.Fatalf("two images: status = %d", code)
+	}
+	if code := upload(2); code != http.StatusTooManyRequests {
+		t.Fatalf("four images in a row: status = %d, want 429", code)
+	}
+	if code := upload(0); code != http.StatusOK {
+		t.Fatalf("text comment: status = %d, want it not to be limited by images", code)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	_, proxies, _ := net.ParseCIDR("10.0.0.0/8")
+	trusted := []*net.IPNet{proxies}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		want       string
+	}{
+		{name: "direct", remoteAddr: "203.0.113.1:5000", want: "203.0.113.1"},
+		{name: "spoofed header from untrusted peer", remoteAddr: "203.0.113.1:5000", forwarded: "1.2.3.4", want: "203.0.113.1"},
+		{name: "via trusted proxy", remoteAddr: "10.0.0.2:5000", forwarded: "198.51.100.7", want: "198.51.100.7"},
+		{name: "client prepends fake address", remoteAddr: "10.0.0.2:5000", forwarded: "1.2.3.4, 198.51.100.7", want: "198.51.100.7"},
+		{name: "chain of trusted proxies", remoteAddr: "10.0.0.2:5000", forwarded: "198.51.100.7, 10.0.0.3", want: "198.51.100.7"},
+		{name: "trusted proxy without header", remoteAddr: "10.0.0.2:5000", want: "10.0.0.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/submit-post", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if got := clientIP(req, trusted); got != tt.want {
+				t.Errorf("clientIP = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,20 +3,53 @@ package transport
 import (
 	"net/http"
 
+	"1337b04rd/internal/config"
 	"1337b04rd/internal/ports/left"
 	"1337b04rd/internal/ports/right"
 	"1337b04rd/pkg/logger"
 )
 
-func SetupRoutes(service left.APIPort, logger *logger.CustomLogger, imageStorage right.ImageStorage, router *http.ServeMux) {
-	h := NewPostHandler(service, logger, imageStorage)
+func SetupRoutes(service left.APIPort, logger *logger.CustomLogger, imageStorage right.ImageStorage, cfg config.HTTPConfig, csrf *CSRF, moderators *ModeratorAuth, limiter *RateLimiter, router *http.ServeMux) {
+	h := NewPostHandler(service, logger, imageStorage, cfg, csrf, moderators)
+
+	// Маршруты, которые создают пользователя при первом обращении. Лимиты проверяются
+	// раньше, чтобы отклонённые запросы не создавали пользователей.
+	withUser := func(handler http.HandlerFunc, limits ...Middleware) http.Handler {
+		return Chain(handler, append(limits, RequireSession(service, SessionCookie{Secure: cfg.CookieSecure}))...)
+	}
 
 	router.HandleFunc("GET /catalog", h.HandleCatalog)
 	router.HandleFunc("GET /post/{id}", h.HandleGetPost)
 	router.HandleFunc("GET /archive", h.HandleArchiveList)
 	router.HandleFunc("GET /archive/post/{id}", h.HandleGetArchivedPost)
-	router.HandleFunc("GET /create-post", h.HandleCreatePostForm) // форма создания
-	router.HandleFunc("POST /submit-post", h.HandleSubmitPost)    // отправка формы
-	router.HandleFunc("POST /post/submit-comment", h.HandleAddComment)
+	router.Handle("GET /create-post", withUser(h.HandleCreatePostForm))               // форма создания
+	router.Handle("POST /submit-post", withUser(h.HandleSubmitPost, limiter.Posts())) // отправка формы
+	router.Handle("POST /post/submit-comment", withUser(h.HandleAddComment, limiter.Comments()))
+	router.Handle("GET /profile", withUser(h.HandleProfile))
+	router.Handle("POST /profile", withUser(h.HandleSetUsername))
 	router.HandleFunc("GET /images/", h.ServeImage)
+
+	// JSON API
+	router.HandleFunc("GET /api/v1/catalog", h.APIListCatalog)
+	router.HandleFunc("GET /api/v1/posts/{id}", h.APIGetPost)
+	router.Handle("POST /api/v1/posts", withUser(h.APICreatePost, limiter.Posts()))
+	router.Handle("POST /api/v1/posts/{id}/comments", withUser(h.APIAddComment, limiter.Comments()))
+	router.HandleFunc("GET /api/v1/archive", h.APIListArchive)
+	router.HandleFunc("GET /api/v1/archive/{id}", h.APIGetArchivedPost)
+	router.HandleFunc("GET /api/v1/me", h.APIGetMe)
+	router.Handle("PATCH /api/v1/me", withUser(h.APIUpdateMe))
+
+	// Модерация доступна, только если заданы учётные данные модератора
+	if moderators == nil {
+		return
+	}
+	router.HandleFunc("GET /mod/login", h.HandleModLoginForm)
+	router.Handle("POST /mod/login", Chain(http.HandlerFunc(h.HandleModLogin), limiter.Logins()))
+	router.HandleFunc("POST /mod/logout", h.HandleModLogout)
+	router.Handle("GET /mod", RequireModerator(http.HandlerFunc(h.HandleModLog)))
+	router.Handle("POST /mod/post/{id}/delete", RequireModerator(http.HandlerFunc(h.HandleModDeletePost)))
+	router.Handle("POST /mod/post/{id}/remove-images", RequireModerator(http.HandlerFunc(h.HandleModRemovePostImages)))
+	router.Handle("POST /mod/post/{id}/lock", RequireModerator(http.HandlerFunc(h.HandleModLockPost)))
+	router.Handle("POST /mod/post/{id}/pin", RequireModerator(http.HandlerFunc(h.HandleModPinPost)))
+	router.Handle("POST /mod/comment", RequireModerator(http.HandlerFunc(h.HandleModComment)))
 }
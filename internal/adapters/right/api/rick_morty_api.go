@@ -1,11 +1,13 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"math/rand"
 	"net/http"
+	"sync"
 	"time"
 
 	"1337b04rd/internal/domain"
@@ -29,82 +31,99 @@ type CharacterResponse struct {
 	Image string `json:"image"`
 }
 
+// RickAndMortyAPI получает персонажей из rickandmortyapi.com. Сеть при создании
+// не нужна: общее число персонажей запрашивается при первом обращении и
+// запрашивается повторно, пока не будет получено.
 type RickAndMortyAPI struct {
-	client          *http.Client
+	client *http.Client
+
+	mu              sync.Mutex
 	totalCharacters int
 }
 
-func NewRickAndMortyAPI() (*RickAndMortyAPI, error) {
-	api := &RickAndMortyAPI{
+func NewRickAndMortyAPI() *RickAndMortyAPI {
+	return &RickAndMortyAPI{
 		client: &http.Client{Timeout: 10 * time.Second},
 	}
-
-	// При инициализации узнаём общее количество персонажей
-	if err := api.fetchTotalCharacters(); err != nil {
-		return nil, fmt.Errorf("failed to init RickAndMortyAPI: %w", err)
-	}
-
-	return api, nil
 }
 
-func (c *RickAndMortyAPI) fetchTotalCharacters() error {
-	url := fmt.Sprintf("%s/character", baseURL)
-	resp, err := c.client.Get(url)
-	if err != nil {
-		return fmt.Errorf("fetch characters count error: %w", err)
-	}
-	defer resp.Body.Close()
+func (c *RickAndMortyAPI) fetchTotalCharacters(ctx context.Context) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("RickMorty API status: %d", resp.StatusCode)
+	if c.totalCharacters > 0 {
+		return c.totalCharacters, nil
 	}
 
 	var data charactersResponse
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return fmt.Errorf("decode characters count error: %w", err)
+	if err := c.get(ctx, fmt.Sprintf("%s/character", baseURL), &data); err != nil {
+		return 0, fmt.Errorf("fetch characters count error: %w", err)
+	}
+	if data.Info.Count == 0 {
+		return 0, errors.New("no characters available")
 	}
 
 	c.totalCharacters = data.Info.Count
-	if c.totalCharacters == 0 {
-		return errors.New("no characters available")
-	}
+	return c.totalCharacters, nil
+}
 
-	return nil
+func (c *RickAndMortyAPI) CharacterCount(ctx context.Context) (int, error) {
+	return c.fetchTotalCharacters(ctx)
 }
 
-func (c *RickAndMortyAPI) GetRandomAvatar() (*domain.User, error) {
-	if c.totalCharacters == 0 {
-		return nil, errors.New("no characters available")
+// Ping проверяет, что API отвечает и из него можно получить персонажа
+func (c *RickAndMortyAPI) Ping(ctx context.Context) error {
+	if _, err := c.fetchTotalCharacters(ctx); err != nil {
+		return err
 	}
-
-	// Генерируем случайный ID в пределах доступных персонажей
-	randomID := rand.Intn(c.totalCharacters) + 1
-	return c.GetRandomAvatarByID(randomID)
+	var data CharacterResponse
+	return c.get(ctx, fmt.Sprintf("%s/character/1", baseURL), &data)
 }
 
-func (c *RickAndMortyAPI) GetRandomAvatarByID(id int) (*domain.User, error) {
-	url := fmt.Sprintf("%s/character/%d", baseURL, id)
-	resp, err := c.client.Get(url)
+func (c *RickAndMortyAPI) GetRandomAvatar(ctx context.Context) (*domain.Character, error) {
+	total, err := c.fetchTotalCharacters(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("RickMorty GET error: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("RickMorty API status: %d", resp.StatusCode)
-	}
+	// Генерируем случайный ID в пределах доступных персонажей
+	randomID := rand.Intn(total) + 1
+	return c.GetRandomAvatarByID(ctx, randomID)
+}
 
+func (c *RickAndMortyAPI) GetRandomAvatarByID(ctx context.Context, id int) (*domain.Character, error) {
 	var data CharacterResponse
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return nil, fmt.Errorf("decode error: %w", err)
+	if err := c.get(ctx, fmt.Sprintf("%s/character/%d", baseURL, id), &data); err != nil {
+		return nil, err
 	}
 
 	if data.Image == "" || data.Name == "" {
 		return nil, errors.New("character missing name or image")
 	}
 
-	return &domain.User{
-		Username: data.Name,
+	return &domain.Character{
+		ID:       data.ID,
+		Name:     data.Name,
 		ImageURL: data.Image,
 	}, nil
 }
+
+func (c *RickAndMortyAPI) get(ctx context.Context, url string, dst any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("RickMorty GET error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("RickMorty API status: %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(dst); err != nil {
+		return fmt.Errorf("decode error: %w", err)
+	}
+	return nil
+}
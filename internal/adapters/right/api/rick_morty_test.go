@@ -1,43 +1,39 @@
 package api
 
 import (
+	"context"
 	"log"
 	"math/rand/v2"
 	"testing"
 )
 
 func TestRickyAndMorty_GetAllCharacters(t *testing.T) {
-	r, err := NewRickAndMortyAPI()
-	if err != nil {
-		t.Fatalf("failed to create Rick and Morty API client: %v", err)
-	}
+	r := NewRickAndMortyAPI()
 
-	data, err := r.GetRandomAvatar()
+	data, err := r.GetRandomAvatar(context.Background())
 	if err != nil {
-		t.Errorf("failed to get random avatar: %v", err)
+		t.Fatalf("failed to get random avatar: %v", err)
 	}
-	log.Println(data.ImageURL, data.Username)
+	log.Println(data.ImageURL, data.Name)
 }
 
 func TestRickyAndMorty_GetCharacterByID(t *testing.T) {
-	r, err := NewRickAndMortyAPI()
-	if r == nil {
-		t.Fatalf("failed to create Rick and Morty API client: %v", err)
-	}
-	data, err := r.GetRandomAvatarByID(rand.IntN(820))
+	r := NewRickAndMortyAPI()
+
+	data, err := r.GetRandomAvatarByID(context.Background(), rand.IntN(820)+1)
 	if err != nil {
-		t.Errorf("failed to get random avatar by ID: %v", err)
+		t.Fatalf("failed to get random avatar by ID: %v", err)
 	}
-	log.Println(data.ImageURL, data.Username)
+	log.Println(data.ImageURL, data.Name)
 }
 
 func TestFetchTotalCharachter(t *testing.T) {
-	r, err := NewRickAndMortyAPI()
+	r := NewRickAndMortyAPI()
+
+	total, err := r.fetchTotalCharacters(context.Background())
 	if err != nil {
-		t.Fatalf("failed to create Rick and Morty API client: %v", err)
+		t.Fatalf("failed to fetch characters count: %v", err)
 	}
 
-	data := r.fetchTotalCharacters()
-
-	log.Println(data)
+	log.Println(total)
 }
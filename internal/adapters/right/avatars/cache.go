@@ -0,0 +1,235 @@
+// Package avatars содержит реализации right.AvatarProvider поверх внешних источников.
+package avatars
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"1337b04rd/internal/domain"
+	"1337b04rd/internal/ports/right"
+)
+
+const (
+	// upstreamCooldown — сколько не обращаться к upstream после сбоя,
+	// чтобы новые посетители не ждали таймаута недоступного API
+	upstreamCooldown = time.Minute
+	maxAvatarSize    = 2 << 20
+)
+
+// avatarExtensions — допустимые типы аватаров и расширения их объектов в хранилище
+var avatarExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// Cache — декоратор AvatarProvider, который запоминает полученных персонажей в БД,
+// а их аватары — в хранилище изображений. Пока upstream недоступен, персонажи
+// выдаются из кэша. Сохранение в кэш — best effort: сбой не мешает выдать персонажа.
+type Cache struct {
+	upstream right.AvatarProvider
+	repo     right.CharacterRepository
+	storage  right.ImageStorage
+	client   *http.Client
+
+	mu          sync.Mutex
+	unavailable time.Time
+}
+
+func NewCache(upstream right.AvatarProvider, repo right.CharacterRepository, storage right.ImageStorage) *Cache {
+	return &Cache{
+		upstream: upstream,
+		repo:     repo,
+		storage:  storage,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Ping считает провайдера готовым, если в кэше есть персонажи или отвечает upstream
+func (c *Cache) Ping(ctx context.Context) error {
+	count, err := c.repo.CountCharacters(ctx)
+	if err != nil {
+		return fmt.Errorf("count cached characters: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+	if p, ok := c.upstream.(interface{ Ping(context.Context) error }); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+// CharacterCount берёт число персонажей у upstream, а пока он недоступен —
+// число закэшированных, чтобы номера по возможности попадали в кэш
+func (c *Cache) CharacterCount(ctx context.Context) (int, error) {
+	if c.upstreamAvailable() {
+		count, err := c.upstream.CharacterCount(ctx)
+		if err == nil {
+			return count, nil
+		}
+		c.markUnavailable(err)
+	}
+
+	count, err := c.repo.CountCharacters(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("count cached characters: %w", err)
+	}
+	if count == 0 {
+		return 0, fmt.Errorf("avatar provider is unavailable and cache is empty: %w", domain.ErrNotFound)
+	}
+	return count, nil
+}
+
+func (c *Cache) GetRandomAvatar(ctx context.Context) (*domain.Character, error) {
+	if c.upstreamAvailable() {
+		character, err := c.upstream.GetRandomAvatar(ctx)
+		if err == nil {
+			return c.remember(ctx, character), nil
+		}
+		c.markUnavailable(err)
+	}
+
+	character, err := c.repo.RandomCharacter(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("avatar provider is unavailable and cache is empty: %w", err)
+	}
+	return character, nil
+}
+
+// GetRandomAvatarByID обращается к upstream только за персонажами, которых нет в кэше.
+// Если upstream недоступен, возвращает случайного закэшированного персонажа.
+func (c *Cache) GetRandomAvatarByID(ctx context.Context, id int) (*domain.Character, error) {
+	cached, err := c.repo.GetCharacter(ctx, id)
+	if err == nil {
+		return cached, nil
+	}
+	if !errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("get cached character %d: %w", id, err)
+	}
+
+	if c.upstreamAvailable() {
+		character, err := c.upstream.GetRandomAvatarByID(ctx, id)
+		if err == nil {
+			return c.remember(ctx, character), nil
+		}
+		c.markUnavailable(err)
+	}
+
+	character, err := c.repo.RandomCharacter(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("avatar provider is unavailable and cache is empty: %w", err)
+	}
+	return character, nil
+}
+
+// Seed заполняет кэш персонажами из списка, пропуская уже закэшированных.
+// Если fetchImages, аватары скачиваются в хранилище; иначе остаются внешние ссылки.
+// Возвращает число добавленных персонажей.
+func (c *Cache) Seed(ctx context.Context, characters []domain.Character, fetchImages bool) (int, error) {
+	added := 0
+	for i := range characters {
+		character := characters[i]
+		if character.ID <= 0 || character.Name == "" || character.ImageURL == "" {
+			return added, fmt.Errorf("character #%d: id, name and image are required", i)
+		}
+
+		if _, err := c.repo.GetCharacter(ctx, character.ID); err == nil {
+			continue
+		} else if !errors.Is(err, domain.ErrNotFound) {
+			return added, fmt.Errorf("get cached character %d: %w", character.ID, err)
+		}
+
+		if fetchImages {
+			if url, err := c.storeAvatar(ctx, &character); err == nil {
+				character.ImageURL = url
+			}
+		}
+		if err := c.repo.SaveCharacter(ctx, &character); err != nil {
+			return added, fmt.Errorf("save character %d: %w", character.ID, err)
+		}
+		added++
+	}
+	return added, nil
+}
+
+// remember возвращает закэшированную копию персонажа, а если её нет — сохраняет
+// аватар в хранилище и запоминает персонажа со ссылкой на локальную копию
+func (c *Cache) remember(ctx context.Context, character *domain.Character) *domain.Character {
+	if cached, err := c.repo.GetCharacter(ctx, character.ID); err == nil {
+		return cached
+	}
+
+	url, err := c.storeAvatar(ctx, character)
+	if err != nil {
+		// Без локальной копии персонаж не кэшируется, чтобы попытаться снова в следующий раз
+		return character
+	}
+
+	stored := &domain.Character{ID: character.ID, Name: character.Name, ImageURL: url}
+	if err := c.repo.SaveCharacter(ctx, stored); err != nil {
+		return character
+	}
+	return stored
+}
+
+// storeAvatar скачивает аватар персонажа и сохраняет его как avatar_<id>.<ext>
+func (c *Cache) storeAvatar(ctx context.Context, character *domain.Character) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, character.ImageURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("download avatar: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("download avatar: status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAvatarSize+1))
+	if err != nil {
+		return "", fmt.Errorf("download avatar: %w", err)
+	}
+	if len(data) > maxAvatarSize {
+		return "", errors.New("avatar is too large")
+	}
+
+	// Тип определяется по содержимому: отдаём со своего домена только изображения
+	contentType := http.DetectContentType(data)
+	ext, ok := avatarExtensions[contentType]
+	if !ok {
+		return "", fmt.Errorf("avatar has unsupported type %s", contentType)
+	}
+
+	name := fmt.Sprintf("avatar_%d%s", character.ID, ext)
+	if err := c.storage.UploadImage(ctx, name, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		return "", fmt.Errorf("store avatar: %w", err)
+	}
+	return domain.ImageURL(name), nil
+}
+
+func (c *Cache) upstreamAvailable() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return time.Now().After(c.unavailable)
+}
+
+// markUnavailable выключает обращения к upstream на upstreamCooldown.
+// Отмена запроса клиентом не считается сбоем upstream.
+func (c *Cache) markUnavailable(err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.unavailable = time.Now().Add(upstreamCooldown)
+}
@@ -0,0 +1,177 @@
+package avatars
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"1337b04rd/internal/adapters/right/localfs"
+	"1337b04rd/internal/domain"
+)
+
+type fakeUpstream struct {
+	character *domain.Character
+	err       error
+	calls     int
+}
+
+func (u *fakeUpstream) CharacterCount(ctx context.Context) (int, error) {
+	u.calls++
+	if u.err != nil {
+		return 0, u.err
+	}
+	return 826, nil
+}
+
+func (u *fakeUpstream) GetRandomAvatar(ctx context.Context) (*domain.Character, error) {
+	if u.err != nil {
+		u.calls++
+		return nil, u.err
+	}
+	return u.GetRandomAvatarByID(ctx, u.character.ID)
+}
+
+func (u *fakeUpstream) GetRandomAvatarByID(ctx context.Context, id int) (*domain.Character, error) {
+	u.calls++
+	if u.err != nil {
+		return nil, u.err
+	}
+	c := *u.character
+	c.ID = id
+	return &c, nil
+}
+
+type memoryCharacters map[int]domain.Character
+
+func (m memoryCharacters) SaveCharacter(ctx context.Context, c *domain.Character) error {
+	m[c.ID] = *c
+	return nil
+}
+
+func (m memoryCharacters) GetCharacter(ctx context.Context, id int) (*domain.Character, error) {
+	c, ok := m[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &c, nil
+}
+
+func (m memoryCharacters) RandomCharacter(ctx context.Context) (*domain.Character, error) {
+	for _, c := range m {
+		return &c, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m memoryCharacters) CountCharacters(ctx context.Context) (int, error) {
+	return len(m), nil
+}
+
+func newAvatarServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 8, 8))); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(buf.Bytes())
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCache_StoresAvatarLocally(t *testing.T) {
+	srv := newAvatarServer(t)
+	storage, err := localfs.NewImageStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := memoryCharacters{}
+	upstream := &fakeUpstream{character: &domain.Character{ID: 7, Name: "Abradolf Lincler", ImageURL: srv.URL + "/7.jpeg"}}
+	cache := NewCache(upstream, repo, storage)
+
+	character, err := cache.GetRandomAvatar(context.Background())
+	if err != nil {
+		t.Fatalf("get avatar: %v", err)
+	}
+	if character.ImageURL != domain.ImageURL("avatar_7.png") {
+		t.Errorf("image url = %q, want local copy", character.ImageURL)
+	}
+	if _, ok := repo[7]; !ok {
+		t.Errorf("character is not cached")
+	}
+	obj, err := storage.GetImage(context.Background(), "avatar_7.png")
+	if err != nil {
+		t.Fatalf("avatar is not stored: %v", err)
+	}
+	obj.Body.Close()
+
+	// Закэшированный персонаж выдаётся без обращения к upstream
+	if _, err := cache.GetRandomAvatarByID(context.Background(), 7); err != nil {
+		t.Fatalf("get cached avatar: %v", err)
+	}
+	if upstream.calls != 1 {
+		t.Errorf("upstream calls = %d, want 1", upstream.calls)
+	}
+}
+
+func TestCache_ServesFromCacheWhenUpstreamIsDown(t *testing.T) {
+	repo := memoryCharacters{1: {ID: 1, Name: "Rick Sanchez", ImageURL: "/images/avatar_1.jpg"}}
+	upstream := &fakeUpstream{err: errors.New("no route to host")}
+	cache := NewCache(upstream, repo, nil)
+
+	for i := 0; i < 3; i++ {
+		character, err := cache.GetRandomAvatarByID(context.Background(), 42)
+		if err != nil {
+			t.Fatalf("get avatar: %v", err)
+		}
+		if character.Name != "Rick Sanchez" {
+			t.Errorf("character = %+v, want cached Rick Sanchez", character)
+		}
+	}
+	if upstream.calls != 1 {
+		t.Errorf("upstream calls = %d, want 1 during cooldown", upstream.calls)
+	}
+	if err := cache.Ping(context.Background()); err != nil {
+		t.Errorf("ping with non-empty cache: %v", err)
+	}
+}
+
+func TestCache_EmptyCacheAndUpstreamDown(t *testing.T) {
+	cache := NewCache(&fakeUpstream{err: errors.New("timeout")}, memoryCharacters{}, nil)
+
+	if _, err := cache.GetRandomAvatar(context.Background()); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("err = %v, want domain.ErrNotFound", err)
+	}
+}
+
+func TestCache_Seed(t *testing.T) {
+	repo := memoryCharacters{1: {ID: 1, Name: "Rick Sanchez", ImageURL: "/images/avatar_1.jpg"}}
+	cache := NewCache(&fakeUpstream{err: errors.New("offline")}, repo, nil)
+
+	added, err := cache.Seed(context.Background(), []domain.Character{
+		{ID: 1, Name: "Rick Sanchez", ImageURL: "https://example.com/1.jpeg"},
+		{ID: 2, Name: "Morty Smith", ImageURL: "https://example.com/2.jpeg"},
+	}, false)
+	if err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	if added != 1 {
+		t.Errorf("added = %d, want 1", added)
+	}
+	if repo[1].ImageURL != "/images/avatar_1.jpg" {
+		t.Errorf("seed overwrote cached character: %+v", repo[1])
+	}
+	if repo[2].Name != "Morty Smith" {
+		t.Errorf("character 2 = %+v", repo[2])
+	}
+
+	if _, err := cache.Seed(context.Background(), []domain.Character{{ID: 3}}, false); err == nil {
+		t.Errorf("seed accepted a character without name and image")
+	}
+}
@@ -0,0 +1,43 @@
+package avatars
+
+import (
+	"context"
+
+	"1337b04rd/internal/domain"
+	"1337b04rd/internal/ports/right"
+)
+
+// Fallback обращается к secondary, если primary вернул ошибку.
+// Обычно primary — Rick and Morty API с кэшем, secondary — Identicon.
+type Fallback struct {
+	primary   right.AvatarProvider
+	secondary right.AvatarProvider
+}
+
+func NewFallback(primary, secondary right.AvatarProvider) *Fallback {
+	return &Fallback{primary: primary, secondary: secondary}
+}
+
+func (f *Fallback) CharacterCount(ctx context.Context) (int, error) {
+	count, err := f.primary.CharacterCount(ctx)
+	if err != nil {
+		return f.secondary.CharacterCount(ctx)
+	}
+	return count, nil
+}
+
+func (f *Fallback) GetRandomAvatar(ctx context.Context) (*domain.Character, error) {
+	character, err := f.primary.GetRandomAvatar(ctx)
+	if err != nil {
+		return f.secondary.GetRandomAvatar(ctx)
+	}
+	return character, nil
+}
+
+func (f *Fallback) GetRandomAvatarByID(ctx context.Context, id int) (*domain.Character, error) {
+	character, err := f.primary.GetRandomAvatarByID(ctx, id)
+	if err != nil {
+		return f.secondary.GetRandomAvatarByID(ctx, id)
+	}
+	return character, nil
+}
@@ -0,0 +1,140 @@
+package avatars
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math/rand"
+
+	"1337b04rd/internal/domain"
+	"1337b04rd/internal/ports/right"
+)
+
+const (
+	identiconGrid    = 5
+	identiconCell    = 40
+	identiconPadding = 20
+	// identiconStep взаимно просто с числом имён, поэтому соседние номера
+	// получают непохожие имена, а все имена пула различны
+	identiconStep = 617
+)
+
+var adjectives = []string{
+	"Brave", "Calm", "Clever", "Cosmic", "Curious", "Dizzy", "Eager", "Fancy",
+	"Fierce", "Fuzzy", "Gentle", "Grumpy", "Happy", "Hasty", "Jolly", "Lazy",
+	"Lucky", "Mighty", "Nimble", "Noisy", "Plucky", "Proud", "Quiet", "Rusty",
+	"Shy", "Sleepy", "Sneaky", "Spicy", "Swift", "Tiny", "Witty", "Zesty",
+}
+
+var animals = []string{
+	"Badger", "Beaver", "Bison", "Camel", "Cobra", "Crane", "Ferret", "Gecko",
+	"Hamster", "Heron", "Hyena", "Ibis", "Koala", "Lemur", "Lynx", "Marmot",
+	"Moose", "Newt", "Ocelot", "Otter", "Panda", "Pelican", "Puffin", "Raccoon",
+	"Raven", "Salmon", "Sloth", "Tapir", "Toucan", "Walrus", "Wombat", "Yak",
+}
+
+// Identicon генерирует персонажей без внешних API: имя из прилагательного и животного,
+// аватар — симметричный узор 5×5, однозначно определяемый номером персонажа.
+// Картинки сохраняются в хранилище при первом обращении.
+type Identicon struct {
+	storage right.ImageStorage
+}
+
+func NewIdenticon(storage right.ImageStorage) *Identicon {
+	return &Identicon{storage: storage}
+}
+
+func (p *Identicon) CharacterCount(ctx context.Context) (int, error) {
+	return len(adjectives) * len(animals), nil
+}
+
+func (p *Identicon) GetRandomAvatar(ctx context.Context) (*domain.Character, error) {
+	count, _ := p.CharacterCount(ctx)
+	return p.GetRandomAvatarByID(ctx, rand.Intn(count)+1)
+}
+
+// GetRandomAvatarByID принимает любой положительный номер: номера за пределами
+// пула получают имена по кругу, но собственный узор
+func (p *Identicon) GetRandomAvatarByID(ctx context.Context, id int) (*domain.Character, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("character %d: %w", id, domain.ErrNotFound)
+	}
+
+	name := fmt.Sprintf("identicon_%d.png", id)
+	if err := p.ensureImage(ctx, name, id); err != nil {
+		return nil, err
+	}
+
+	return &domain.Character{
+		ID:       id,
+		Name:     identiconName(id),
+		ImageURL: domain.ImageURL(name),
+	}, nil
+}
+
+// ensureImage рисует аватар, только если его ещё нет в хранилище
+func (p *Identicon) ensureImage(ctx context.Context, objectName string, id int) error {
+	obj, err := p.storage.GetImage(ctx, objectName)
+	if err == nil {
+		obj.Body.Close()
+		return nil
+	}
+	if !errors.Is(err, domain.ErrNotFound) {
+		return fmt.Errorf("check identicon: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, renderIdenticon(id)); err != nil {
+		return fmt.Errorf("encode identicon: %w", err)
+	}
+	if err := p.storage.UploadImage(ctx, objectName, &buf, int64(buf.Len()), "image/png"); err != nil {
+		return fmt.Errorf("store identicon: %w", err)
+	}
+	return nil
+}
+
+func identiconName(id int) string {
+	n := len(adjectives) * len(animals)
+	idx := (id - 1) * identiconStep % n
+	return adjectives[idx/len(animals)] + " " + animals[idx%len(animals)]
+}
+
+// renderIdenticon рисует узор, зеркальный относительно вертикальной оси:
+// левые три столбца берутся из битов хэша номера, цвет — из его первых байтов
+func renderIdenticon(id int) image.Image {
+	var seed [8]byte
+	binary.BigEndian.PutUint64(seed[:], uint64(id))
+	sum := sha256.Sum256(seed[:])
+
+	fg := color.RGBA{R: 48 + sum[0]%160, G: 48 + sum[1]%160, B: 48 + sum[2]%160, A: 255}
+	bg := color.RGBA{R: 240, G: 240, B: 240, A: 255}
+
+	size := identiconGrid*identiconCell + 2*identiconPadding
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(img, img.Bounds(), &image.Uniform{bg}, image.Point{}, draw.Src)
+
+	bits := binary.BigEndian.Uint32(sum[3:7])
+	for row := 0; row < identiconGrid; row++ {
+		for col := 0; col < (identiconGrid+1)/2; col++ {
+			if bits&1 == 1 {
+				fillCell(img, row, col, fg)
+				fillCell(img, row, identiconGrid-1-col, fg)
+			}
+			bits >>= 1
+		}
+	}
+	return img
+}
+
+func fillCell(img *image.RGBA, row, col int, c color.Color) {
+	x := identiconPadding + col*identiconCell
+	y := identiconPadding + row*identiconCell
+	draw.Draw(img, image.Rect(x, y, x+identiconCell, y+identiconCell), &image.Uniform{c}, image.Point{}, draw.Src)
+}
@@ -0,0 +1,105 @@
+package avatars
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image/png"
+	"io"
+	"testing"
+
+	"1337b04rd/internal/adapters/right/localfs"
+	"1337b04rd/internal/domain"
+)
+
+func TestIdenticon_Deterministic(t *testing.T) {
+	storage, err := localfs.NewImageStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewIdenticon(storage)
+	ctx := context.Background()
+
+	first, err := p.GetRandomAvatarByID(ctx, 42)
+	if err != nil {
+		t.Fatalf("get avatar: %v", err)
+	}
+	second, err := p.GetRandomAvatarByID(ctx, 42)
+	if err != nil {
+		t.Fatalf("get avatar again: %v", err)
+	}
+	if *first != *second {
+		t.Errorf("same id gave %+v and %+v", first, second)
+	}
+	if first.ImageURL != domain.ImageURL("identicon_42.png") {
+		t.Errorf("image url = %q", first.ImageURL)
+	}
+
+	obj, err := storage.GetImage(ctx, "identicon_42.png")
+	if err != nil {
+		t.Fatalf("identicon is not stored: %v", err)
+	}
+	defer obj.Body.Close()
+	data, err := io.ReadAll(obj.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decode png: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != b.Dy() || b.Dx() == 0 {
+		t.Errorf("identicon is %v, want a square", b)
+	}
+
+	var want bytes.Buffer
+	png.Encode(&want, renderIdenticon(42))
+	if !bytes.Equal(data, want.Bytes()) {
+		t.Errorf("stored identicon differs from a fresh render")
+	}
+}
+
+func TestIdenticon_UniqueNames(t *testing.T) {
+	p := NewIdenticon(nil)
+	count, err := p.CharacterCount(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[string]int, count)
+	for id := 1; id <= count; id++ {
+		name := identiconName(id)
+		if prev, ok := seen[name]; ok {
+			t.Fatalf("ids %d and %d share name %q", prev, id, name)
+		}
+		seen[name] = id
+	}
+}
+
+func TestFallback(t *testing.T) {
+	storage, err := localfs.NewImageStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	primary := &fakeUpstream{err: errors.New("offline")}
+	f := NewFallback(primary, NewIdenticon(storage))
+	ctx := context.Background()
+
+	count, err := f.CharacterCount(ctx)
+	if err != nil || count != len(adjectives)*len(animals) {
+		t.Errorf("count = %d, %v", count, err)
+	}
+	character, err := f.GetRandomAvatarByID(ctx, 3)
+	if err != nil {
+		t.Fatalf("get avatar: %v", err)
+	}
+	if character.Name != identiconName(3) {
+		t.Errorf("character = %+v, want identicon", character)
+	}
+
+	primary.err = nil
+	primary.character = &domain.Character{Name: "Summer Smith", ImageURL: "/images/avatar_3.jpg"}
+	if character, err := f.GetRandomAvatarByID(ctx, 3); err != nil || character.Name != "Summer Smith" {
+		t.Errorf("character = %+v, %v; want primary", character, err)
+	}
+}
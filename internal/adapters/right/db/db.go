@@ -4,8 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"time"
 
 	"1337b04rd/internal/domain"
+	"1337b04rd/pkg"
 )
 
 type Repo struct {
@@ -24,8 +27,11 @@ func (r *Repo) ListCatalog(ctx context.Context) ([]*domain.PostSummary, error) {
 			p.post_id, 
 			p.title, 
 			p.image_url, 
+			COALESCE(p.thumbnail_url, ''), 
 			p.created_at, 
-			c.username
+			COALESCE(p.author_name, c.username),
+			p.is_locked,
+			p.is_pinned
 		FROM 
 			Post p
 		JOIN 
@@ -33,6 +39,7 @@ func (r *Repo) ListCatalog(ctx context.Context) ([]*domain.PostSummary, error) {
 		WHERE 
 			p.is_deleted = FALSE
 		ORDER BY 
+			p.is_pinned DESC,
 			p.created_at DESC;
 	`)
 	if err != nil {
@@ -43,7 +50,7 @@ func (r *Repo) ListCatalog(ctx context.Context) ([]*domain.PostSummary, error) {
 	var posts []*domain.PostSummary
 	for rows.Next() {
 		var post domain.PostSummary
-		if err := rows.Scan(&post.ID, &post.Title, &post.ImageURL, &post.CreatedAt, &post.Author); err != nil {
+		if err := rows.Scan(&post.ID, &post.Title, &post.ImageURL, &post.ThumbnailURL, &post.CreatedAt, &post.Author, &post.Locked, &post.Pinned); err != nil {
 			return nil, err
 		}
 		posts = append(posts, &post)
@@ -53,20 +60,27 @@ func (r *Repo) ListCatalog(ctx context.Context) ([]*domain.PostSummary, error) {
 
 func (r *Repo) GetPostByID(ctx context.Context, id string) (*domain.Post, error) {
 	row := r.Conn.QueryRowContext(ctx, `
-		SELECT p.post_id, p.title, p.content, p.image_url, p.created_at, u.username, u.user_id
+		SELECT p.post_id, p.title, p.content, p.image_url, COALESCE(p.thumbnail_url, ''), p.created_at, COALESCE(p.author_name, u.username), u.user_id,
+			p.is_locked, p.is_pinned
 		FROM Post p
 		JOIN Client u ON p.user_id = u.user_id
 		WHERE p.post_id = $1
 	`, id)
 
 	var post domain.Post
-	if err := row.Scan(&post.ID, &post.Title, &post.Content, &post.ImageURL, &post.CreatedAt, &post.Author, &post.AuthorID); err != nil {
+	if err := row.Scan(&post.ID, &post.Title, &post.Content, &post.ImageURL, &post.ThumbnailURL, &post.CreatedAt, &post.Author, &post.AuthorID, &post.Locked, &post.Pinned); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, err
+			return nil, domain.ErrNotFound
 		}
 		return nil, err
 	}
 
+	attachments, err := r.listPostAttachments(ctx, post.ID)
+	if err != nil {
+		return nil, err
+	}
+	post.Attachments = attachments
+
 	comments, err := r.getCommentsByPostID(ctx, post.ID)
 	if err != nil {
 		return nil, err
@@ -77,11 +91,59 @@ func (r *Repo) GetPostByID(ctx context.Context, id string) (*domain.Post, error)
 }
 
 func (r *Repo) CreatePost(ctx context.Context, post *domain.Post) error {
-	_, err := r.Conn.ExecContext(ctx, `
-		INSERT INTO Post (post_id, title, content, image_url, user_id)
-		VALUES ($1, $2, $3, $4, $5)
-	`, post.ID, post.Title, post.Content, post.ImageURL, post.Author) // Author = user_id
-	return err
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO Post (post_id, title, content, image_url, thumbnail_url, user_id, author_name, created_at, expires_at)
+			VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, (SELECT username FROM Client WHERE user_id = $6), $7, $8)
+		`, post.ID, post.Title, post.Content, post.ImageURL, post.ThumbnailURL, post.Author, post.CreatedAt, post.ExpiresAt) // Author = user_id
+		if err != nil {
+			return err
+		}
+		return insertAttachments(ctx, tx, "post_id", post.ID, post.Attachments)
+	})
+}
+
+func (r *Repo) GetPostExpiry(ctx context.Context, id string) (*domain.PostExpiry, error) {
+	row := r.Conn.QueryRowContext(ctx, `
+		SELECT p.post_id, p.created_at, p.expires_at, p.is_deleted,
+			(SELECT COUNT(*) FROM Comment c WHERE c.post_id = p.post_id),
+			p.is_locked, p.is_pinned
+		FROM Post p
+		WHERE p.post_id = $1
+	`, id)
+
+	var expiry domain.PostExpiry
+	if err := row.Scan(&expiry.PostID, &expiry.CreatedAt, &expiry.ExpiresAt, &expiry.Archived, &expiry.Comments, &expiry.Locked, &expiry.Pinned); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &expiry, nil
+}
+
+// ListPostExpiries возвращает сроки активных тредов; закреплённые не истекают и не попадают в список
+func (r *Repo) ListPostExpiries(ctx context.Context) ([]domain.PostExpiry, error) {
+	rows, err := r.Conn.QueryContext(ctx, `
+		SELECT post_id, created_at, expires_at
+		FROM Post
+		WHERE is_deleted = FALSE AND is_pinned = FALSE
+		ORDER BY expires_at ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var expiries []domain.PostExpiry
+	for rows.Next() {
+		var expiry domain.PostExpiry
+		if err := rows.Scan(&expiry.PostID, &expiry.CreatedAt, &expiry.ExpiresAt); err != nil {
+			return nil, err
+		}
+		expiries = append(expiries, expiry)
+	}
+	return expiries, rows.Err()
 }
 
 func (r *Repo) GetPosts(ctx context.Context) ([]domain.Post, error) {
@@ -92,7 +154,7 @@ func (r *Repo) GetPosts(ctx context.Context) ([]domain.Post, error) {
     p.content, 
     p.image_url, 
     p.created_at, 
-    u.username 
+    COALESCE(p.author_name, u.username) 
 FROM 
     Post p
 JOIN 
@@ -131,8 +193,9 @@ func (r *Repo) ListArchiveCatalog(ctx context.Context) ([]*domain.PostSummary, e
 			p.post_id, 
 			p.title, 
 			p.image_url, 
+			COALESCE(p.thumbnail_url, ''), 
 			p.created_at, 
-			c.username
+			COALESCE(p.author_name, c.username)
 		FROM 
 			Post p
 		JOIN 
@@ -150,7 +213,7 @@ func (r *Repo) ListArchiveCatalog(ctx context.Context) ([]*domain.PostSummary, e
 	var posts []*domain.PostSummary
 	for rows.Next() {
 		var post domain.PostSummary
-		if err := rows.Scan(&post.ID, &post.Title, &post.ImageURL, &post.CreatedAt, &post.Author); err != nil {
+		if err := rows.Scan(&post.ID, &post.Title, &post.ImageURL, &post.ThumbnailURL, &post.CreatedAt, &post.Author); err != nil {
 			return nil, err
 		}
 		posts = append(posts, &post)
@@ -160,20 +223,26 @@ func (r *Repo) ListArchiveCatalog(ctx context.Context) ([]*domain.PostSummary, e
 
 func (r *Repo) GetArchivedPostByID(ctx context.Context, id string) (*domain.Post, error) {
 	row := r.Conn.QueryRowContext(ctx, `
-		SELECT p.post_id, p.title, p.content, p.image_url, p.created_at, u.username, u.user_id
+		SELECT p.post_id, p.title, p.content, p.image_url, COALESCE(p.thumbnail_url, ''), p.created_at, COALESCE(p.author_name, u.username), u.user_id
 		FROM Post p
 		JOIN Client u ON p.user_id = u.user_id
 		WHERE p.post_id = $1 AND is_deleted = TRUE
 	`, id)
 
 	var post domain.Post
-	if err := row.Scan(&post.ID, &post.Title, &post.Content, &post.ImageURL, &post.CreatedAt, &post.Author, &post.AuthorID); err != nil {
+	if err := row.Scan(&post.ID, &post.Title, &post.Content, &post.ImageURL, &post.ThumbnailURL, &post.CreatedAt, &post.Author, &post.AuthorID); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, domain.ErrNotFound
 		}
 		return nil, err
 	}
 
+	attachments, err := r.listPostAttachments(ctx, post.ID)
+	if err != nil {
+		return nil, err
+	}
+	post.Attachments = attachments
+
 	comments, err := r.getCommentsByPostID(ctx, post.ID)
 	if err != nil {
 		return nil, err
@@ -185,7 +254,7 @@ func (r *Repo) GetArchivedPostByID(ctx context.Context, id string) (*domain.Post
 
 func (r *Repo) ArchivePostByID(ctx context.Context, id string) (*domain.Post, error) {
 	_, err := r.Conn.ExecContext(ctx, `
-		UPDATE Post SET is_deleted = TRUE WHERE post_id = $1
+		UPDATE Post SET is_deleted = TRUE, archived_at = NOW() WHERE post_id = $1 AND is_deleted = FALSE
 	`, id)
 	if err != nil {
 		return nil, err
@@ -193,24 +262,137 @@ func (r *Repo) ArchivePostByID(ctx context.Context, id string) (*domain.Post, er
 	return r.GetArchivedPostByID(ctx, id)
 }
 
+// ArchiveExpiredPost архивирует пост, только если его срок действительно истёк.
+// Возвращает false, если пост уже в архиве, закреплён или был продлён (например, другой репликой).
+func (r *Repo) ArchiveExpiredPost(ctx context.Context, id string, now time.Time) (bool, error) {
+	res, err := r.Conn.ExecContext(ctx, `
+		UPDATE Post SET is_deleted = TRUE, archived_at = $2
+		WHERE post_id = $1 AND is_deleted = FALSE AND is_pinned = FALSE AND expires_at <= $2
+	`, id, now)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (r *Repo) ArchiveExpiredPosts(ctx context.Context, now time.Time) ([]string, error) {
+	rows, err := r.Conn.QueryContext(ctx, `
+		UPDATE Post SET is_deleted = TRUE, archived_at = $1
+		WHERE is_deleted = FALSE AND is_pinned = FALSE AND expires_at <= $1
+		RETURNING post_id
+	`, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// PurgeArchivedPosts удаляет архивные треды, ушедшие в архив не позже before.
+// Комментарии и вложения удаляются каскадно, а их объекты подберёт сборщик мусора.
+func (r *Repo) PurgeArchivedPosts(ctx context.Context, before time.Time) ([]string, error) {
+	rows, err := r.Conn.QueryContext(ctx, `
+		DELETE FROM Post
+		WHERE is_deleted = TRUE AND archived_at <= $1
+		RETURNING post_id
+	`, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
 // CommentRepository --------------------
 
-func (r *Repo) AddComment(ctx context.Context, postID string, comment *domain.Comment) error {
-	_, err := r.Conn.ExecContext(ctx, `
-		INSERT INTO Comment (comment_id, content,avatar, post_id, user_id)
-		 VALUES ($1, $2, $3, $4, $5)
-	`, comment.ID, comment.Content, comment.AvatarLink, postID, comment.Author)
-	return err
+// AddComment продлевает жизнь треда и сохраняет комментарий в одной транзакции.
+// Срок продлевается, только если тред ещё активен на момент now; закреплённый тред
+// остаётся активным и после своего срока. UPDATE блокирует строку поста, поэтому
+// архивация не может закрыть тред между проверкой и записью комментария.
+// Срок никогда не сокращается, поэтому конкурирующие реплики не затирают друг друга.
+func (r *Repo) AddComment(ctx context.Context, postID string, comment *domain.Comment, now, expiresAt time.Time) error {
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `
+			UPDATE Post SET expires_at = GREATEST(expires_at, $3)
+			WHERE post_id = $1 AND is_deleted = FALSE AND (expires_at > $2 OR is_pinned)
+		`, postID, now, expiresAt)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return domain.ErrPostArchived
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO Comment (comment_id, content, avatar, post_id, parent_comment_id, user_id, author_name)
+			VALUES ($1, $2, $3, $4, NULLIF($5, '')::uuid, $6, (SELECT username FROM Client WHERE user_id = $6))
+		`, comment.ID, comment.Content, comment.AvatarLink, postID, comment.ParentID, comment.Author)
+		if err != nil {
+			return err
+		}
+		return insertAttachments(ctx, tx, "comment_id", comment.ID, comment.Attachments)
+	})
 }
 
 func (r *Repo) ReplyToComment(ctx context.Context, postID string, parentID string, comment *domain.Comment) error {
 	_, err := r.Conn.ExecContext(ctx, `
-		INSERT INTO Comment (content, avatar, post_id, parent_comment_id, user_id)
-		VALUES ($1, $2, $3, $4, $5)
-	`, comment.Content, comment.AvatarLink, postID, parentID, comment.Author)
+		INSERT INTO Comment (comment_id, content, avatar, post_id, parent_comment_id, user_id, author_name)
+		VALUES ($1, $2, $3, $4, $5, $6, (SELECT username FROM Client WHERE user_id = $6))
+	`, comment.ID, comment.Content, comment.AvatarLink, postID, parentID, comment.Author)
 	return err
 }
 
+// GetCommentLocation одним запросом по первичным ключам находит пост комментария и его состояние.
+// Номер не в формате UUID не может принадлежать комментарию, поэтому до базы не доходит.
+func (r *Repo) GetCommentLocation(ctx context.Context, commentID string) (*domain.CommentLocation, error) {
+	if !pkg.IsUUID(commentID) {
+		return nil, domain.ErrNotFound
+	}
+
+	row := r.Conn.QueryRowContext(ctx, `
+		SELECT c.comment_id, c.post_id, p.is_deleted, p.is_locked
+		FROM Comment c
+		JOIN Post p ON p.post_id = c.post_id
+		WHERE c.comment_id = $1
+	`, commentID)
+
+	var location domain.CommentLocation
+	if err := row.Scan(&location.CommentID, &location.PostID, &location.PostArchived, &location.PostLocked); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &location, nil
+}
+
 // UserRepository --------------------
 
 func (r *Repo) CreateUser(ctx context.Context, user *domain.User) error {
@@ -238,15 +420,81 @@ func (r *Repo) GetUserByID(ctx context.Context, userID string) (*domain.User, er
 	return &user, nil
 }
 
-func (r *Repo) GetMaxCharacterID(ctx context.Context) (int, error) {
-	row := r.Conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM Client`)
+// UpdateUsername меняет имя пользователя. Уже опубликованные посты и комментарии
+// хранят имя на момент публикации и не меняются.
+func (r *Repo) UpdateUsername(ctx context.Context, userID, username string) error {
+	res, err := r.Conn.ExecContext(ctx, `
+		UPDATE Client SET username = $2 WHERE user_id = $1
+	`, userID, username)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// NextCharacterSlot возвращает следующий номер из character_slot_seq, начиная с 1.
+// nextval не откатывается вместе с транзакцией, поэтому номера уникальны и при
+// параллельных запросах, но при сбоях в них могут быть пропуски.
+func (r *Repo) NextCharacterSlot(ctx context.Context) (int64, error) {
+	var slot int64
+	if err := r.Conn.QueryRowContext(ctx, `SELECT nextval('character_slot_seq')`).Scan(&slot); err != nil {
+		return 0, err
+	}
+	return slot, nil
+}
+
+// CharacterRepository --------------------
+
+// SaveCharacter добавляет персонажа в кэш или обновляет его имя и аватар
+func (r *Repo) SaveCharacter(ctx context.Context, character *domain.Character) error {
+	_, err := r.Conn.ExecContext(ctx, `
+		INSERT INTO Character (character_id, name, image_url)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (character_id) DO UPDATE
+		SET name = EXCLUDED.name, image_url = EXCLUDED.image_url, cached_at = NOW()
+	`, character.ID, character.Name, character.ImageURL)
+	return err
+}
+
+func (r *Repo) GetCharacter(ctx context.Context, id int) (*domain.Character, error) {
+	return scanCharacter(r.Conn.QueryRowContext(ctx, `
+		SELECT character_id, name, image_url FROM Character WHERE character_id = $1
+	`, id))
+}
+
+func (r *Repo) RandomCharacter(ctx context.Context) (*domain.Character, error) {
+	return scanCharacter(r.Conn.QueryRowContext(ctx, `
+		SELECT character_id, name, image_url FROM Character ORDER BY random() LIMIT 1
+	`))
+}
+
+func (r *Repo) CountCharacters(ctx context.Context) (int, error) {
 	var count int
-	if err := row.Scan(&count); err != nil {
+	if err := r.Conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM Character`).Scan(&count); err != nil {
 		return 0, err
 	}
 	return count, nil
 }
 
+func scanCharacter(row *sql.Row) (*domain.Character, error) {
+	var c domain.Character
+	if err := row.Scan(&c.ID, &c.Name, &c.ImageURL); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
 // SessionRepository --------------------
 
 func (r *Repo) GetSession(ctx context.Context, sessionID string) (*domain.Session, error) {
@@ -274,15 +522,47 @@ func (r *Repo) SaveSession(ctx context.Context, session *domain.Session) error {
 	return err
 }
 
+// TouchSession сдвигает срок только вперёд, поэтому параллельные запросы
+// одной сессии не укорачивают её
+func (r *Repo) TouchSession(ctx context.Context, sessionID string, now, expiresAt time.Time) error {
+	res, err := r.Conn.ExecContext(ctx, `
+		UPDATE Session SET expires_at = GREATEST(expires_at, $3)
+		WHERE session_id = $1 AND expires_at > $2
+	`, sessionID, now, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *Repo) DeleteExpiredSessions(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.Conn.ExecContext(ctx, `
+		DELETE FROM Session WHERE expires_at <= $1
+	`, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
 // Вспомогательная --------------------
 
+// getCommentsByPostID загружает все комментарии поста одним запросом и собирает из них дерево
 func (r *Repo) getCommentsByPostID(ctx context.Context, postID string) ([]domain.Comment, error) {
 	rows, err := r.Conn.QueryContext(ctx, `
-		SELECT c.comment_id, c.content, c.created_at, u.username, c.avatar
+		SELECT c.comment_id, c.parent_comment_id, c.content, c.created_at, COALESCE(c.author_name, u.username), c.avatar
 		FROM Comment c
 		JOIN Client u ON c.user_id = u.user_id
 		WHERE c.post_id = $1
-		ORDER BY c.created_at ASC
+		ORDER BY c.created_at ASC, c.comment_id ASC
 	`, postID)
 	if err != nil {
 		return nil, err
@@ -292,10 +572,160 @@ func (r *Repo) getCommentsByPostID(ctx context.Context, postID string) ([]domain
 	var comments []domain.Comment
 	for rows.Next() {
 		var c domain.Comment
-		if err := rows.Scan(&c.ID, &c.Content, &c.CreatedAt, &c.Author, &c.AvatarLink); err != nil {
+		var parentID sql.NullString
+		if err := rows.Scan(&c.ID, &parentID, &c.Content, &c.CreatedAt, &c.Author, &c.AvatarLink); err != nil {
 			return nil, err
 		}
+		c.ParentID = parentID.String
 		comments = append(comments, c)
 	}
-	return comments, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	attachments, err := r.listCommentAttachments(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range comments {
+		comments[i].Attachments = attachments[comments[i].ID]
+	}
+
+	return buildCommentTree(comments), nil
+}
+
+// buildCommentTree раскладывает отсортированный по времени плоский список комментариев
+// в дерево произвольной глубины, сохраняя порядок внутри каждого уровня.
+// Комментарии с неизвестным родителем попадают на верхний уровень.
+func buildCommentTree(comments []domain.Comment) []domain.Comment {
+	known := make(map[string]struct{}, len(comments))
+	for _, c := range comments {
+		known[c.ID] = struct{}{}
+	}
+
+	var roots []domain.Comment
+	children := make(map[string][]domain.Comment)
+	for _, c := range comments {
+		if _, ok := known[c.ParentID]; ok && c.ParentID != c.ID {
+			children[c.ParentID] = append(children[c.ParentID], c)
+			continue
+		}
+		roots = append(roots, c)
+	}
+
+	var attach func(level []domain.Comment) []domain.Comment
+	attach = func(level []domain.Comment) []domain.Comment {
+		for i := range level {
+			level[i].Replies = attach(children[level[i].ID])
+		}
+		return level
+	}
+
+	return attach(roots)
+}
+
+//  Attachments --------------------
+
+// ListImageURLs возвращает все URL изображений, на которые ссылаются посты, вложения
+// (в том числе архивные) и закэшированные аватары
+func (r *Repo) ListImageURLs(ctx context.Context) ([]string, error) {
+	rows, err := r.Conn.QueryContext(ctx, `
+		SELECT image_url FROM Post WHERE image_url <> ''
+		UNION SELECT thumbnail_url FROM Post WHERE thumbnail_url <> ''
+		UNION SELECT url FROM Attachment
+		UNION SELECT thumbnail_url FROM Attachment WHERE thumbnail_url <> ''
+		UNION SELECT image_url FROM Character
+		UNION SELECT image_url FROM Client WHERE image_url <> ''
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var urls []string
+	for rows.Next() {
+		var url string
+		if err := rows.Scan(&url); err != nil {
+			return nil, err
+		}
+		urls = append(urls, url)
+	}
+	return urls, rows.Err()
+}
+
+func (r *Repo) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertAttachments сохраняет вложения владельца в исходном порядке.
+// ownerColumn — "post_id" или "comment_id".
+func insertAttachments(ctx context.Context, tx *sql.Tx, ownerColumn, ownerID string, attachments []domain.Attachment) error {
+	for i, a := range attachments {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO Attachment (attachment_id, `+ownerColumn+`, position, url, thumbnail_url, width, height, size_bytes, mime_type)
+			VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, $9)
+		`, a.ID, ownerID, i, a.URL, a.ThumbnailURL, a.Width, a.Height, a.Size, a.MIMEType)
+		if err != nil {
+			return fmt.Errorf("insert attachment %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (r *Repo) listPostAttachments(ctx context.Context, postID string) ([]domain.Attachment, error) {
+	rows, err := r.Conn.QueryContext(ctx, `
+		SELECT a.attachment_id, a.url, COALESCE(a.thumbnail_url, ''), a.width, a.height, a.size_bytes, a.mime_type
+		FROM Attachment a
+		WHERE a.post_id = $1
+		ORDER BY a.position
+	`, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []domain.Attachment
+	for rows.Next() {
+		var a domain.Attachment
+		if err := rows.Scan(&a.ID, &a.URL, &a.ThumbnailURL, &a.Width, &a.Height, &a.Size, &a.MIMEType); err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+// listCommentAttachments одним запросом загружает вложения всех комментариев поста
+func (r *Repo) listCommentAttachments(ctx context.Context, postID string) (map[string][]domain.Attachment, error) {
+	rows, err := r.Conn.QueryContext(ctx, `
+		SELECT a.comment_id, a.attachment_id, a.url, COALESCE(a.thumbnail_url, ''), a.width, a.height, a.size_bytes, a.mime_type
+		FROM Attachment a
+		JOIN Comment c ON c.comment_id = a.comment_id
+		WHERE c.post_id = $1
+		ORDER BY a.comment_id, a.position
+	`, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := make(map[string][]domain.Attachment)
+	for rows.Next() {
+		var commentID string
+		var a domain.Attachment
+		if err := rows.Scan(&commentID, &a.ID, &a.URL, &a.ThumbnailURL, &a.Width, &a.Height, &a.Size, &a.MIMEType); err != nil {
+			return nil, err
+		}
+		attachments[commentID] = append(attachments[commentID], a)
+	}
+	return attachments, rows.Err()
 }
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"1337b04rd/internal/domain"
+)
+
+func TestBuildCommentTree(t *testing.T) {
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(min int) time.Time { return base.Add(time.Duration(min) * time.Minute) }
+
+	// Плоский список в порядке created_at, как его возвращает запрос
+	flat := []domain.Comment{
+		{ID: "a", CreatedAt: at(0)},
+		{ID: "b", CreatedAt: at(1)},
+		{ID: "a1", ParentID: "a", CreatedAt: at(2)},
+		{ID: "a1x", ParentID: "a1", CreatedAt: at(3)},
+		{ID: "a2", ParentID: "a", CreatedAt: at(4)},
+		{ID: "a1xy", ParentID: "a1x", CreatedAt: at(5)},
+		{ID: "orphan", ParentID: "missing", CreatedAt: at(6)},
+	}
+
+	tree := buildCommentTree(flat)
+
+	if got := ids(tree); got != "a,b,orphan" {
+		t.Fatalf("roots = %s", got)
+	}
+	if got := ids(tree[0].Replies); got != "a1,a2" {
+		t.Fatalf("replies of a = %s", got)
+	}
+	deep := tree[0].Replies[0].Replies
+	if got := ids(deep); got != "a1x" {
+		t.Fatalf("replies of a1 = %s", got)
+	}
+	if got := ids(deep[0].Replies); got != "a1xy" {
+		t.Fatalf("replies of a1x = %s", got)
+	}
+	if len(tree[1].Replies) != 0 {
+		t.Fatalf("expected no replies for b, got %d", len(tree[1].Replies))
+	}
+}
+
+func ids(comments []domain.Comment) string {
+	var s string
+	for i, c := range comments {
+		if i > 0 {
+			s += ","
+		}
+		s += c.ID
+	}
+	return s
+}
+
+func TestGetCommentLocation_MalformedID(t *testing.T) {
+	// Без соединения: некорректный номер отклоняется до запроса к базе
+	r := &Repo{}
+	for _, id := range []string{"", "42", "not-a-uuid", "0190b7c2-5d3e-7f00-8a1b-2c3d4e5f6a7"} {
+		if _, err := r.GetCommentLocation(context.Background(), id); !errors.Is(err, domain.ErrNotFound) {
+			t.Errorf("GetCommentLocation(%q) = %v, want ErrNotFound", id, err)
+		}
+	}
+}
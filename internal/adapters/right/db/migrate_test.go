@@ -0,0 +1,72 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeMigrations(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadMigrations(t *testing.T) {
+	dir := writeMigrations(t, map[string]string{
+		"0010_later.up.sql":  "SELECT 10;",
+		"0002_second.up.sql": "SELECT 2;",
+		"0001_init.up.sql":   "SELECT 1;",
+		"0001_init.down.sql": "SELECT -1;",
+		"README.md":          "not a migration",
+	})
+
+	migrations, err := LoadMigrations(dir)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	var versions []int64
+	for _, m := range migrations {
+		versions = append(versions, m.Version)
+	}
+	if len(versions) != 3 || versions[0] != 1 || versions[1] != 2 || versions[2] != 10 {
+		t.Fatalf("unexpected order: %v", versions)
+	}
+	if migrations[0].Down != "SELECT -1;" || migrations[1].Down != "" {
+		t.Fatalf("down scripts not paired: %+v", migrations[:2])
+	}
+}
+
+func TestLoadMigrations_Invalid(t *testing.T) {
+	tests := map[string]map[string]string{
+		"no up script":      {"0001_init.down.sql": "SELECT 1;"},
+		"duplicate version": {"0001_a.up.sql": "SELECT 1;", "0001_b.up.sql": "SELECT 1;"},
+	}
+
+	for name, files := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := LoadMigrations(writeMigrations(t, files)); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestRepoMigrationsAreLoadable(t *testing.T) {
+	migrations, err := LoadMigrations("../../../../migrations")
+	if err != nil {
+		t.Fatalf("load repo migrations: %v", err)
+	}
+	for _, m := range migrations {
+		if strings.TrimSpace(m.Down) == "" {
+			t.Errorf("migration %04d_%s has no down script", m.Version, m.Name)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"1337b04rd/internal/domain"
+)
+
+//  ModerationRepository --------------------
+
+// DeletePost удаляет тред целиком, в том числе архивный.
+// Комментарии и вложения удаляются каскадно.
+func (r *Repo) DeletePost(ctx context.Context, id string) error {
+	res, err := r.Conn.ExecContext(ctx, `DELETE FROM Post WHERE post_id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return expectRow(res)
+}
+
+// DeleteComment удаляет комментарий вместе со всеми ответами на него и их вложениями
+func (r *Repo) DeleteComment(ctx context.Context, id string) error {
+	res, err := r.Conn.ExecContext(ctx, `DELETE FROM Comment WHERE comment_id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return expectRow(res)
+}
+
+// RemovePostImages отвязывает от поста все изображения, а заодно убирает те же изображения
+// из остальных постов и комментариев: объекты адресуются по содержимому и общие для всех.
+// Возвращает URL изображений вместе с превью.
+func (r *Repo) RemovePostImages(ctx context.Context, id string) ([]string, error) {
+	var urls []string
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		var imageURL, thumbnailURL string
+		err := tx.QueryRowContext(ctx, `
+			SELECT COALESCE(image_url, ''), COALESCE(thumbnail_url, '')
+			FROM Post WHERE post_id = $1
+			FOR UPDATE
+		`, id).Scan(&imageURL, &thumbnailURL)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrNotFound
+			}
+			return err
+		}
+		urls = appendURLs(urls, imageURL, thumbnailURL)
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE Post SET image_url = '', thumbnail_url = NULL WHERE post_id = $1
+		`, id); err != nil {
+			return err
+		}
+
+		attached, err := deleteAttachments(ctx, tx, "post_id", id)
+		if err != nil {
+			return err
+		}
+		urls = append(urls, attached...)
+		return clearImageReferences(ctx, tx, urls)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return urls, nil
+}
+
+// RemoveCommentImages удаляет вложения комментария и, как RemovePostImages, все остальные
+// ссылки на те же изображения. Возвращает URL вместе с превью.
+func (r *Repo) RemoveCommentImages(ctx context.Context, id string) ([]string, error) {
+	var urls []string
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		var exists bool
+		if err := tx.QueryRowContext(ctx, `
+			SELECT EXISTS (SELECT 1 FROM Comment WHERE comment_id = $1)
+		`, id).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return domain.ErrNotFound
+		}
+
+		attached, err := deleteAttachments(ctx, tx, "comment_id", id)
+		if err != nil {
+			return err
+		}
+		urls = attached
+		return clearImageReferences(ctx, tx, urls)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return urls, nil
+}
+
+func (r *Repo) SetPostLocked(ctx context.Context, id string, locked bool) error {
+	res, err := r.Conn.ExecContext(ctx, `UPDATE Post SET is_locked = $2 WHERE post_id = $1`, id, locked)
+	if err != nil {
+		return err
+	}
+	return expectRow(res)
+}
+
+// SetPostPinned закрепляет или открепляет активный тред. При откреплении срок жизни
+// не опускается ниже expiresAt, чтобы тред не ушёл в архив сразу.
+func (r *Repo) SetPostPinned(ctx context.Context, id string, pinned bool, expiresAt time.Time) error {
+	res, err := r.Conn.ExecContext(ctx, `
+		UPDATE Post SET
+			is_pinned = $2,
+			expires_at = CASE WHEN $2 THEN expires_at ELSE GREATEST(expires_at, $3) END
+		WHERE post_id = $1 AND is_deleted = FALSE
+	`, id, pinned, expiresAt)
+	if err != nil {
+		return err
+	}
+	return expectRow(res)
+}
+
+func (r *Repo) LogModeration(ctx context.Context, entry *domain.ModerationLogEntry) error {
+	return r.Conn.QueryRowContext(ctx, `
+		INSERT INTO ModerationLog (moderator, action, target_type, target_id, details, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING log_id
+	`, entry.Moderator, entry.Action, entry.TargetType, entry.TargetID, entry.Details, entry.CreatedAt).Scan(&entry.ID)
+}
+
+// ListModerationLog возвращает последние limit записей журнала, начиная с новых
+func (r *Repo) ListModerationLog(ctx context.Context, limit int) ([]domain.ModerationLogEntry, error) {
+	rows, err := r.Conn.QueryContext(ctx, `
+		SELECT log_id, moderator, action, target_type, target_id, details, created_at
+		FROM ModerationLog
+		ORDER BY created_at DESC, log_id DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []domain.ModerationLogEntry
+	for rows.Next() {
+		var e domain.ModerationLogEntry
+		if err := rows.Scan(&e.ID, &e.Moderator, &e.Action, &e.TargetType, &e.TargetID, &e.Details, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// deleteAttachments удаляет вложения владельца и возвращает URL их изображений и превью.
+// ownerColumn — "post_id" или "comment_id".
+func deleteAttachments(ctx context.Context, tx *sql.Tx, ownerColumn, ownerID string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `
+		DELETE FROM Attachment WHERE `+ownerColumn+` = $1
+		RETURNING url, COALESCE(thumbnail_url, '')
+	`, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var urls []string
+	for rows.Next() {
+		var url, thumbnailURL string
+		if err := rows.Scan(&url, &thumbnailURL); err != nil {
+			return nil, err
+		}
+		urls = appendURLs(urls, url, thumbnailURL)
+	}
+	return urls, rows.Err()
+}
+
+// clearImageReferences убирает изображения urls из всех постов и вложений, включая архивные,
+// чтобы после удаления объектов ни на одной странице не осталось битых ссылок
+func clearImageReferences(ctx context.Context, tx *sql.Tx, urls []string) error {
+	for _, url := range urls {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE Post SET image_url = '', thumbnail_url = NULL
+			WHERE image_url = $1 OR thumbnail_url = $1
+		`, url); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM Attachment WHERE url = $1 OR thumbnail_url = $1
+		`, url); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendURLs(urls []string, candidates ...string) []string {
+	for _, url := range candidates {
+		if url != "" {
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
+
+// expectRow возвращает domain.ErrNotFound, если запрос не затронул ни одной строки
+func expectRow(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
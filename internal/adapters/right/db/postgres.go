@@ -1,19 +1,13 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
 
-	_ "github.com/jackc/pgx/v5/stdlib"
-)
+	"1337b04rd/internal/config"
 
-const (
-	host     = "db"
-	port     = "5432"
-	user     = "postgres"
-	password = "postgres"
-	dbname   = "1337board"
+	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
 type Postgres struct {
@@ -21,23 +15,29 @@ type Postgres struct {
 	Repo
 }
 
-func NewPostgres() *Postgres {
-	psqlInfo := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		host, port, user, password, dbname,
-	)
-
-	db, err := sql.Open("pgx", psqlInfo)
+func NewPostgres(cfg config.DBConfig) (*Postgres, error) {
+	db, err := sql.Open("pgx", cfg.DSN())
 	if err != nil {
-		log.Fatalf("Failed to open a DB connection: %v", err)
+		return nil, fmt.Errorf("failed to open a DB connection: %w", err)
+	}
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxOpenConns)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to the database: %w", err)
 	}
-
-	log.Println("Successfully connected to the database!")
 
 	return &Postgres{
 		db:   db,
 		Repo: Repo{Conn: db},
-	}
+	}, nil
+}
+
+func (p *Postgres) Ping(ctx context.Context) error {
+	return p.db.PingContext(ctx)
 }
 
 func (p *Postgres) Close() error {
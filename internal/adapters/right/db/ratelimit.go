@@ -0,0 +1,77 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+	"time"
+
+	"1337b04rd/internal/domain"
+)
+
+// RateLimitStore хранит вёдра токенов в Postgres, чтобы лимиты были общими для всех реплик.
+// Ведро блокируется на время пересчёта, поэтому параллельные запросы не тратят токены дважды.
+type RateLimitStore struct {
+	Conn *sql.DB
+}
+
+func NewRateLimitStore(db *sql.DB) *RateLimitStore {
+	return &RateLimitStore{Conn: db}
+}
+
+func (s *RateLimitStore) Take(ctx context.Context, takes []domain.RateLimitTake, now time.Time) (bool, time.Duration, error) {
+	// Вёдра блокируются в порядке ключей, чтобы параллельные запросы не ждали друг друга по кругу
+	takes = slices.Clone(takes)
+	slices.SortFunc(takes, func(a, b domain.RateLimitTake) int { return strings.Compare(a.Key, b.Key) })
+
+	tx, err := s.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, 0, err
+	}
+	defer tx.Rollback()
+
+	tokens := make([]float64, len(takes))
+	var wait time.Duration
+	for i, take := range takes {
+		// Новое ведро создаётся полным; существующее не меняется
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO RateLimitBucket (bucket_key, tokens, updated_at)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (bucket_key) DO NOTHING
+		`, take.Key, float64(take.Limit.Burst), now); err != nil {
+			return false, 0, err
+		}
+
+		var updated time.Time
+		if err := tx.QueryRowContext(ctx, `
+			SELECT tokens, updated_at FROM RateLimitBucket WHERE bucket_key = $1 FOR UPDATE
+		`, take.Key).Scan(&tokens[i], &updated); err != nil {
+			return false, 0, err
+		}
+
+		tokens[i] = take.Limit.Refill(tokens[i], now.Sub(updated))
+		wait = max(wait, take.Limit.Wait(tokens[i], take.Cost))
+	}
+	// Если хотя бы одно ведро отказало, транзакция откатывается и токены остаются на месте
+	if wait > 0 {
+		return false, wait, nil
+	}
+
+	for i, take := range takes {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE RateLimitBucket SET tokens = $2, updated_at = $3 WHERE bucket_key = $1
+		`, take.Key, tokens[i]-float64(min(take.Cost, take.Limit.Burst)), now); err != nil {
+			return false, 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return false, 0, err
+	}
+	return true, 0, nil
+}
+
+func (s *RateLimitStore) Prune(ctx context.Context, before time.Time) error {
+	_, err := s.Conn.ExecContext(ctx, `DELETE FROM RateLimitBucket WHERE updated_at < $1`, before)
+	return err
+}
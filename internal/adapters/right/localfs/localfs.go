@@ -0,0 +1,186 @@
+package localfs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"1337b04rd/internal/domain"
+	"1337b04rd/internal/ports/right"
+)
+
+// objectNameRe — допустимые имена объектов: без разделителей пути и без ведущей точки,
+// поэтому "..", "a/b" и абсолютные пути отвергаются ещё до обращения к диску.
+var objectNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,127}$`)
+
+var ErrInvalidObjectName = errors.New("invalid object name")
+
+// ImageStorage хранит изображения в каталоге на диске. Файлы раскладываются
+// по подкаталогам по первым байтам sha256 от имени, чтобы не держать все
+// объекты в одном каталоге. Запись атомарна: временный файл переименовывается
+// в итоговый только после fsync.
+type ImageStorage struct {
+	root string
+}
+
+func NewImageStorage(dir string) (*ImageStorage, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("resolve storage dir: %w", err)
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("create storage dir: %w", err)
+	}
+	return &ImageStorage{root: root}, nil
+}
+
+// Ping проверяет, что каталог хранилища существует и доступен для записи
+func (s *ImageStorage) Ping(ctx context.Context) error {
+	f, err := os.CreateTemp(s.root, ".ping-*")
+	if err != nil {
+		return fmt.Errorf("storage dir is not writable: %w", err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+func (s *ImageStorage) UploadImage(ctx context.Context, objectName string, r io.Reader, size int64, contentType string) error {
+	path, err := s.path(objectName)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create shard dir: %w", err)
+	}
+
+	if err := writeAtomic(ctx, path, r); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+func (s *ImageStorage) GetImage(ctx context.Context, objectName string) (*right.ImageObject, error) {
+	path, err := s.path(objectName)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("image %s: %w", objectName, domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	// Объекты неизменяемы: новый файл всегда получает новое имя или новый mtime
+	return &right.ImageObject{
+		Body:    f,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		ETag:    fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size()),
+	}, nil
+}
+
+func (s *ImageStorage) DeleteImage(ctx context.Context, objectName string) error {
+	path, err := s.path(objectName)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove file: %w", err)
+	}
+	return nil
+}
+
+// ListImages обходит каталоги-шарды; временные файлы незавершённых загрузок пропускаются
+func (s *ImageStorage) ListImages(ctx context.Context) ([]right.ObjectInfo, error) {
+	var objects []right.ObjectInfo
+	err := filepath.WalkDir(s.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() || !objectNameRe.MatchString(d.Name()) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			// Файл могли удалить между чтением каталога и stat
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		objects = append(objects, right.ObjectInfo{
+			Name:    d.Name(),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+	return objects, nil
+}
+
+// path возвращает путь к объекту внутри корня хранилища
+func (s *ImageStorage) path(objectName string) (string, error) {
+	if !objectNameRe.MatchString(objectName) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidObjectName, objectName)
+	}
+	sum := sha256.Sum256([]byte(objectName))
+	shard := hex.EncodeToString(sum[:1])
+	return filepath.Join(s.root, shard, objectName), nil
+}
+
+func writeAtomic(ctx context.Context, path string, r io.Reader) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	// После успешного rename удаление временного файла ничего не делает
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := io.Copy(tmp, readerWithContext{ctx: ctx, r: r}); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// readerWithContext прерывает копирование, если запрос отменён
+type readerWithContext struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r readerWithContext) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
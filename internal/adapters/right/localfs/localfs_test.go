@@ -0,0 +1,50 @@
+package localfs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"1337b04rd/internal/adapters/right/storagetest"
+	"1337b04rd/internal/ports/right"
+)
+
+func TestImageStorage_Conformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) right.ImageStorage {
+		s, err := NewImageStorage(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	})
+}
+
+func TestImageStorage_NoTempFilesLeft(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewImageStorage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "a.png"
+	path, err := s.path(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeAtomic(context.Background(), path, strings.NewReader("data")); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != name {
+		t.Errorf("shard dir contains %v, want only %s", entries, name)
+	}
+}
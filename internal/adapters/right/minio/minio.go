@@ -1,17 +1,18 @@
 package minio
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"io"
-	"mime/multipart"
-	"net/http"
-	"path/filepath"
+	"strings"
 	"time"
 
-	"github.com/minio/minio-go/v7/pkg/credentials"
+	"1337b04rd/internal/config"
+	"1337b04rd/internal/domain"
+	"1337b04rd/internal/ports/right"
+
 	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
 )
 
 type ImageStorage struct {
@@ -19,19 +20,19 @@ type ImageStorage struct {
 	bucketName string
 }
 
-func NewImageStorage(endpoint, accessKey, secretKey, bucketName string, useSSL bool) (*ImageStorage, error) {
-	client, err := connectMinioWithRetry(endpoint, accessKey, secretKey, useSSL, 10, 2*time.Second)
+func NewImageStorage(cfg config.MinioConfig) (*ImageStorage, error) {
+	client, err := connectMinioWithRetry(cfg.Endpoint, cfg.AccessKey, cfg.SecretKey, cfg.UseSSL, cfg.ConnectRetries, cfg.RetryDelay)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to MinIO: %w", err)
 	}
 
-	if err := ensureBucketExists(context.Background(), client, bucketName); err != nil {
+	if err := ensureBucketExists(context.Background(), client, cfg.Bucket); err != nil {
 		return nil, fmt.Errorf("bucket check/create failed: %w", err)
 	}
 
 	return &ImageStorage{
 		client:     client,
-		bucketName: bucketName,
+		bucketName: cfg.Bucket,
 	}, nil
 }
 
@@ -67,41 +68,100 @@ func ensureBucketExists(ctx context.Context, client *minio.Client, bucketName st
 	return nil
 }
 
-func (u *ImageStorage) UploadImage(ctx context.Context, file multipart.File, fileHeader *multipart.FileHeader) (string, error) {
-	defer file.Close()
+// Ping проверяет, что MinIO доступен и бакет существует
+func (u *ImageStorage) Ping(ctx context.Context) error {
+	exists, err := u.client.BucketExists(ctx, u.bucketName)
+	if err != nil {
+		return fmt.Errorf("error checking bucket existence: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("bucket %q does not exist", u.bucketName)
+	}
+	return nil
+}
 
-	extension := filepath.Ext(fileHeader.Filename)
-	objectName := fmt.Sprintf("post_%d%s", time.Now().UnixNano(), extension)
+func (u *ImageStorage) UploadImage(ctx context.Context, objectName string, r io.Reader, size int64, contentType string) error {
+	if err := validateObjectName(objectName); err != nil {
+		return err
+	}
 
-	_, err := u.client.PutObject(ctx, u.bucketName, objectName, file, fileHeader.Size, minio.PutObjectOptions{
-		ContentType: fileHeader.Header.Get("Content-Type"),
+	_, err := u.client.PutObject(ctx, u.bucketName, objectName, r, size, minio.PutObjectOptions{
+		ContentType: contentType,
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to upload file to MinIO: %w", err)
+		return fmt.Errorf("failed to upload file to MinIO: %w", err)
 	}
 
-	return objectName, nil
+	return nil
 }
 
-func (u *ImageStorage) GetImage(ctx context.Context, objectName string) ([]byte, string, error) {
+// GetImage открывает объект без чтения в память: minio.Object сам догружает
+// нужные диапазоны при Read и Seek
+func (u *ImageStorage) GetImage(ctx context.Context, objectName string) (*right.ImageObject, error) {
+	if err := validateObjectName(objectName); err != nil {
+		return nil, err
+	}
+
 	object, err := u.client.GetObject(ctx, u.bucketName, objectName, minio.GetObjectOptions{})
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to get object from MinIO: %w", err)
+		return nil, fmt.Errorf("failed to get object from MinIO: %w", err)
+	}
+
+	info, err := object.Stat()
+	if err != nil {
+		object.Close()
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, fmt.Errorf("image %s: %w", objectName, domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to stat object: %w", err)
+	}
+
+	contentType := info.ContentType
+	// Старые объекты сохранялись с типом от клиента
+	if contentType == "application/octet-stream" {
+		contentType = ""
+	}
+
+	return &right.ImageObject{
+		Body:        object,
+		Size:        info.Size,
+		ContentType: contentType,
+		ModTime:     info.LastModified,
+		ETag:        `"` + strings.Trim(info.ETag, `"`) + `"`,
+	}, nil
+}
+
+func (u *ImageStorage) DeleteImage(ctx context.Context, objectName string) error {
+	if err := validateObjectName(objectName); err != nil {
+		return err
 	}
-	defer object.Close()
 
-	buffer := new(bytes.Buffer)
-	header := make([]byte, 512)
-	n, err := object.Read(header)
-	if err != nil && err != io.EOF {
-		return nil, "", fmt.Errorf("failed to read object header: %w", err)
+	if err := u.client.RemoveObject(ctx, u.bucketName, objectName, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to remove object from MinIO: %w", err)
 	}
-	buffer.Write(header[:n])
+	return nil
+}
 
-	if _, err := io.Copy(buffer, object); err != nil {
-		return nil, "", fmt.Errorf("failed to read full object: %w", err)
+func (u *ImageStorage) ListImages(ctx context.Context) ([]right.ObjectInfo, error) {
+	var objects []right.ObjectInfo
+	for object := range u.client.ListObjects(ctx, u.bucketName, minio.ListObjectsOptions{Recursive: true}) {
+		if object.Err != nil {
+			return nil, fmt.Errorf("failed to list objects in MinIO: %w", object.Err)
+		}
+		objects = append(objects, right.ObjectInfo{
+			Name:    object.Key,
+			Size:    object.Size,
+			ModTime: object.LastModified,
+		})
 	}
+	return objects, nil
+}
 
-	contentType := http.DetectContentType(header[:n])
-	return buffer.Bytes(), contentType, nil
+// validateObjectName не пускает в бакет ключи с путями: имена выбирает приложение,
+// а из URL /images/ приходит только последний сегмент
+func validateObjectName(objectName string) error {
+	if objectName == "" || strings.Contains(objectName, "..") || strings.ContainsAny(objectName, `/\`) {
+		return fmt.Errorf("invalid object name %q", objectName)
+	}
+	return nil
 }
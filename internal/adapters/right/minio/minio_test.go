@@ -0,0 +1,55 @@
+package minio
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"1337b04rd/internal/adapters/right/storagetest"
+	"1337b04rd/internal/config"
+	"1337b04rd/internal/ports/right"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// Тест требует живой MinIO: MINIO_TEST_ENDPOINT=localhost:9000 go test ./...
+func TestImageStorage_Conformance(t *testing.T) {
+	endpoint := os.Getenv("MINIO_TEST_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("MINIO_TEST_ENDPOINT is not set")
+	}
+
+	storagetest.Run(t, func(t *testing.T) right.ImageStorage {
+		cfg := config.MinioConfig{
+			Endpoint:       endpoint,
+			AccessKey:      envOr("MINIO_TEST_ACCESS_KEY", "minioadmin"),
+			SecretKey:      envOr("MINIO_TEST_SECRET_KEY", "minioadmin"),
+			Bucket:         fmt.Sprintf("conformance-%d", time.Now().UnixNano()),
+			ConnectRetries: 1,
+			RetryDelay:     time.Second,
+		}
+		s, err := NewImageStorage(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { removeBucket(s) })
+		return s
+	})
+}
+
+func removeBucket(s *ImageStorage) {
+	ctx := context.Background()
+	for object := range s.client.ListObjects(ctx, s.bucketName, minio.ListObjectsOptions{Recursive: true}) {
+		s.client.RemoveObject(ctx, s.bucketName, object.Key, minio.RemoveObjectOptions{})
+	}
+	s.client.RemoveBucket(ctx, s.bucketName)
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,64 @@
+// Package ratelimit содержит хранилище вёдер токенов в памяти процесса.
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"1337b04rd/internal/domain"
+)
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// MemoryStore — right.RateLimitStore для одной реплики; состояние теряется при перезапуске
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket)}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, takes []domain.RateLimitTake, now time.Time) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Сначала проверяем все вёдра, и только если токенов хватает везде — забираем
+	tokens := make([]float64, len(takes))
+	var wait time.Duration
+	for i, take := range takes {
+		tokens[i] = float64(take.Limit.Burst)
+		if b, ok := s.buckets[take.Key]; ok {
+			tokens[i] = take.Limit.Refill(b.tokens, now.Sub(b.updated))
+		}
+		wait = max(wait, take.Limit.Wait(tokens[i], take.Cost))
+	}
+	if wait > 0 {
+		return false, wait, nil
+	}
+
+	for i, take := range takes {
+		s.buckets[take.Key] = &bucket{
+			tokens:  tokens[i] - float64(min(take.Cost, take.Limit.Burst)),
+			updated: now,
+		}
+	}
+	return true, 0, nil
+}
+
+func (s *MemoryStore) Prune(ctx context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, b := range s.buckets {
+		if b.updated.Before(before) {
+			delete(s.buckets, key)
+		}
+	}
+	return nil
+}
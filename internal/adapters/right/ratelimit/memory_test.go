@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"1337b04rd/internal/domain"
+)
+
+func takes(key string, limit domain.RateLimit, cost int) []domain.RateLimitTake {
+	return []domain.RateLimitTake{{Key: key, Limit: limit, Cost: cost}}
+}
+
+func TestMemoryStore_Take(t *testing.T) {
+	s := NewMemoryStore()
+	ctx := context.Background()
+	limit := domain.RateLimit{Burst: 2, Interval: time.Minute}
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		if ok, _, err := s.Take(ctx, takes("k", limit, 1), now); err != nil || !ok {
+			t.Fatalf("take %d: ok = %v, err = %v", i, ok, err)
+		}
+	}
+	ok, wait, err := s.Take(ctx, takes("k", limit, 1), now)
+	if err != nil || ok || wait != 30*time.Second {
+		t.Fatalf("empty bucket: ok = %v, wait = %v, err = %v; want wait 30s", ok, wait, err)
+	}
+	if ok, _, _ := s.Take(ctx, takes("other", limit, 1), now); !ok {
+		t.Fatalf("buckets are not independent")
+	}
+
+	// Токен восстанавливается за Interval/Burst
+	if ok, _, _ := s.Take(ctx, takes("k", limit, 1), now.Add(30*time.Second)); !ok {
+		t.Fatalf("token is not refilled")
+	}
+
+	// Стоимость больше Burst ограничивается Burst
+	if ok, _, _ := s.Take(ctx, takes("big", limit, 5), now); !ok {
+		t.Fatalf("cost above burst is never allowed")
+	}
+
+	if err := s.Prune(ctx, now.Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.buckets) != 1 {
+		t.Errorf("buckets after prune = %d, want only the recently used one", len(s.buckets))
+	}
+}
+
+func TestMemoryStore_TakeIsAllOrNothing(t *testing.T) {
+	s := NewMemoryStore()
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	roomy := domain.RateLimit{Burst: 5, Interval: time.Minute}
+	tight := domain.RateLimit{Burst: 1, Interval: time.Minute}
+
+	both := []domain.RateLimitTake{{Key: "a", Limit: roomy, Cost: 1}, {Key: "b", Limit: tight, Cost: 1}}
+	if ok, _, _ := s.Take(ctx, both, now); !ok {
+		t.Fatal("first take must be allowed")
+	}
+	ok, wait, err := s.Take(ctx, both, now)
+	if err != nil || ok || wait != time.Minute {
+		t.Fatalf("ok = %v, wait = %v, err = %v; want rejected with wait 1m", ok, wait, err)
+	}
+
+	// Отказ по ведру b не тронул ведро a
+	if got := s.buckets["a"].tokens; got != 4 {
+		t.Errorf("bucket a tokens = %v, want 4", got)
+	}
+}
@@ -0,0 +1,206 @@
+// Package storagetest содержит общий набор проверок для реализаций right.ImageStorage.
+package storagetest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"1337b04rd/internal/domain"
+	"1337b04rd/internal/ports/right"
+)
+
+// pngHeader — сигнатура PNG, по которой хранилища определяют тип содержимого
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
+// Run прогоняет набор проверок на хранилище, созданном newStorage.
+// newStorage вызывается для каждого подтеста и должен возвращать пустое хранилище.
+func Run(t *testing.T, newStorage func(t *testing.T) right.ImageStorage) {
+	t.Run("UploadAndGet", func(t *testing.T) {
+		s := newStorage(t)
+		ctx := context.Background()
+
+		data := append(append([]byte{}, pngHeader...), "payload"...)
+		if err := upload(ctx, s, "cat.png", data); err != nil {
+			t.Fatalf("upload: %v", err)
+		}
+
+		obj, err := s.GetImage(ctx, "cat.png")
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		defer obj.Body.Close()
+
+		got, err := io.ReadAll(obj.Body)
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("get returned %q, want %q", got, data)
+		}
+		if obj.Size != int64(len(data)) {
+			t.Errorf("size = %d, want %d", obj.Size, len(data))
+		}
+		if obj.ContentType != "" && obj.ContentType != "image/png" {
+			t.Errorf("content type = %q, want image/png or empty", obj.ContentType)
+		}
+		if obj.ETag == "" || obj.ModTime.IsZero() {
+			t.Errorf("missing cache metadata: etag %q, modtime %v", obj.ETag, obj.ModTime)
+		}
+	})
+
+	t.Run("Seek", func(t *testing.T) {
+		s := newStorage(t)
+		ctx := context.Background()
+
+		data := append(append([]byte{}, pngHeader...), "0123456789"...)
+		if err := upload(ctx, s, "range.png", data); err != nil {
+			t.Fatalf("upload: %v", err)
+		}
+
+		obj, err := s.GetImage(ctx, "range.png")
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		defer obj.Body.Close()
+
+		offset := int64(len(pngHeader) + 5)
+		if _, err := obj.Body.Seek(offset, io.SeekStart); err != nil {
+			t.Fatalf("seek: %v", err)
+		}
+		got := make([]byte, 3)
+		if _, err := io.ReadFull(obj.Body, got); err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if string(got) != "567" {
+			t.Errorf("read after seek = %q, want 567", got)
+		}
+	})
+
+	t.Run("Overwrite", func(t *testing.T) {
+		s := newStorage(t)
+		ctx := context.Background()
+
+		first := append(append([]byte{}, pngHeader...), "first"...)
+		second := append(append([]byte{}, pngHeader...), "second"...)
+		if err := upload(ctx, s, "same.png", first); err != nil {
+			t.Fatalf("upload: %v", err)
+		}
+		if err := upload(ctx, s, "same.png", second); err != nil {
+			t.Fatalf("second upload: %v", err)
+		}
+
+		got, err := readObject(ctx, s, "same.png")
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		if !bytes.Equal(got, second) {
+			t.Errorf("get returned %q, want %q", got, second)
+		}
+	})
+
+	t.Run("MissingObject", func(t *testing.T) {
+		s := newStorage(t)
+
+		if _, err := s.GetImage(context.Background(), "missing.png"); !errors.Is(err, domain.ErrNotFound) {
+			t.Errorf("get missing: err = %v, want domain.ErrNotFound", err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		s := newStorage(t)
+		ctx := context.Background()
+
+		if err := upload(ctx, s, "x.png", pngHeader); err != nil {
+			t.Fatalf("upload: %v", err)
+		}
+		if err := s.DeleteImage(ctx, "x.png"); err != nil {
+			t.Fatalf("delete: %v", err)
+		}
+		if _, err := s.GetImage(ctx, "x.png"); !errors.Is(err, domain.ErrNotFound) {
+			t.Errorf("get after delete: err = %v, want domain.ErrNotFound", err)
+		}
+		// Повторное удаление не считается ошибкой
+		if err := s.DeleteImage(ctx, "x.png"); err != nil {
+			t.Errorf("second delete: %v", err)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		s := newStorage(t)
+		ctx := context.Background()
+
+		objects, err := s.ListImages(ctx)
+		if err != nil {
+			t.Fatalf("list empty: %v", err)
+		}
+		if len(objects) != 0 {
+			t.Fatalf("empty storage lists %v", objects)
+		}
+
+		data := append(append([]byte{}, pngHeader...), "payload"...)
+		for _, name := range []string{"a.png", "b.png", "c_200.jpg"} {
+			if err := upload(ctx, s, name, data); err != nil {
+				t.Fatalf("upload %s: %v", name, err)
+			}
+		}
+		if err := s.DeleteImage(ctx, "b.png"); err != nil {
+			t.Fatalf("delete: %v", err)
+		}
+
+		objects, err = s.ListImages(ctx)
+		if err != nil {
+			t.Fatalf("list: %v", err)
+		}
+		got := make(map[string]right.ObjectInfo, len(objects))
+		for _, obj := range objects {
+			got[obj.Name] = obj
+		}
+		if len(got) != 2 {
+			t.Fatalf("list returned %v, want a.png and c_200.jpg", objects)
+		}
+		for _, name := range []string{"a.png", "c_200.jpg"} {
+			obj, ok := got[name]
+			if !ok {
+				t.Errorf("%s is not listed", name)
+				continue
+			}
+			if obj.Size != int64(len(data)) || obj.ModTime.IsZero() {
+				t.Errorf("%s: size %d, modtime %v", name, obj.Size, obj.ModTime)
+			}
+		}
+	})
+
+	t.Run("PathTraversal", func(t *testing.T) {
+		s := newStorage(t)
+		ctx := context.Background()
+
+		for _, name := range []string{"../etc/passwd", "..", "a/../../b", "/etc/passwd", `..\windows`} {
+			if err := upload(ctx, s, name, pngHeader); err == nil {
+				t.Errorf("upload %q succeeded, want error", name)
+			}
+			if obj, err := s.GetImage(ctx, name); err == nil {
+				obj.Body.Close()
+				t.Errorf("get %q succeeded, want error", name)
+			}
+			if err := s.DeleteImage(ctx, name); err == nil {
+				t.Errorf("delete %q succeeded, want error", name)
+			}
+		}
+	})
+}
+
+func readObject(ctx context.Context, s right.ImageStorage, name string) ([]byte, error) {
+	obj, err := s.GetImage(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Body.Close()
+	return io.ReadAll(obj.Body)
+}
+
+func upload(ctx context.Context, s right.ImageStorage, name string, data []byte) error {
+	return s.UploadImage(ctx, name, bytes.NewReader(data), int64(len(data)), "image/png")
+}
@@ -2,6 +2,7 @@ package application
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"time"
 
@@ -10,24 +11,58 @@ import (
 
 type App struct {
 	sync.Mutex
-	userService    userService
-	timers         map[string]*time.Timer
+	userService userService
+	timers      map[string]*time.Timer
+	deadlines   map[string]time.Time
+	jobs        sync.WaitGroup
+	// background — периодические задачи, которые останавливаются вместе с ctx запуска
+	background     sync.WaitGroup
+	stopScheduler  context.CancelFunc
+	stopped        bool
 	ArchivePost    func(ctx context.Context, postID string)
 	repo           right.DbPort
 	avatarProvider right.AvatarProvider
 	imageStorage   right.ImageStorage
+	lifetime       LifetimePolicy
+	images         ImagePolicy
+	sessionTTL     time.Duration
 }
 
-func NewApp(pr right.DbPort, ar right.AvatarProvider, is right.ImageStorage, userService userService) *App {
+func NewApp(pr right.DbPort, ar right.AvatarProvider, is right.ImageStorage, userService userService, lifetime LifetimePolicy, images ImagePolicy, sessionTTL time.Duration) *App {
 	return &App{
 		userService:    userService,
 		timers:         make(map[string]*time.Timer),
+		deadlines:      make(map[string]time.Time),
 		repo:           pr,
 		avatarProvider: ar,
 		imageStorage:   is,
+		lifetime:       lifetime,
+		images:         images,
+		sessionTTL:     sessionTTL,
 	}
 }
 
 func (a *App) Timers() map[string]*time.Timer {
 	return a.timers
 }
+
+func (a *App) now() time.Time {
+	return a.lifetime.Now()
+}
+
+// WaitBackground дожидается периодических задач (сборка мусора, очистка сессий)
+// после отмены контекста, с которым они запущены. Вызывать до закрытия пула БД.
+func (app *App) WaitBackground(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		app.background.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("wait for background jobs: %w", ctx.Err())
+	}
+}
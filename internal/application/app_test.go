@@ -9,7 +9,7 @@ import (
 )
 
 func TestCreatePost_TimerAndArchivation(t *testing.T) {
-	a := NewApp(nil, nil, nil, userService{})
+	a := NewApp(nil, nil, nil, userService{}, DefaultLifetimePolicy(), DefaultImagePolicy(), time.Hour)
 
 	post := &domain.Post{ID: "post-123"}
 	called := make(chan string, 1)
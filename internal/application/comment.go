@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"time"
 
 	"1337b04rd/internal/domain"
 )
@@ -22,41 +21,39 @@ func (app *App) AddComment(ctx context.Context, postID string, comment *domain.C
 
 	comment.AvatarLink = author.ImageURL
 
-	app.Lock()
-	defer app.Unlock()
-
-	// Если нет активного таймера, значит пост "не активен"
-	if _, ok := app.timers[postID]; !ok {
-		return fmt.Errorf("post with ID %s is not active", postID)
-	}
-
-	// Останавливаем старый таймер, если был
-	app.timers[postID].Stop()
-
-	// Запускаем новый таймер
-	app.timers[postID] = time.AfterFunc(15*time.Minute, func() {
-		app.Lock()
-		defer app.Unlock()
-
-		if timer, ok := app.timers[postID]; ok {
-			delete(app.timers, postID)
-			go app.archivePost(context.Background(), postID)
-			timer.Stop()
+	expiry, err := app.activePostExpiry(ctx, postID)
+	if err != nil {
+		if errors.Is(err, domain.ErrPostArchived) || errors.Is(err, domain.ErrPostLocked) {
+			return fmt.Errorf("post with ID %s is not active: %w", postID, err)
 		}
-	})
+		return fmt.Errorf("get post expiry: %w", err)
+	}
 
-	err = app.repo.AddComment(ctx, postID, comment)
+	err = app.saveComment(ctx, expiry, comment)
+	if errors.Is(err, domain.ErrPostArchived) {
+		return fmt.Errorf("post with ID %s is not active: %w", postID, err)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to add comment in database: %w", err)
 	}
 	return nil
 }
 
-func (app *App) ReplyToComment(ctx context.Context, parentCommentID string, reply *domain.Comment) error {
-	parentPost, err := app.findPostByCommentID(ctx, parentCommentID)
+func (app *App) ReplyToComment(ctx context.Context, postID, parentCommentID string, reply *domain.Comment) error {
+	// 1. Находим пост родительского комментария: отвечать можно только внутри того же треда
+	location, err := app.repo.GetCommentLocation(ctx, parentCommentID)
 	if err != nil {
 		return fmt.Errorf("parent comment not found: %w", err)
 	}
+	if location.PostID != postID {
+		return fmt.Errorf("parent comment %s is not in post %s: %w", parentCommentID, postID, domain.ErrNotFound)
+	}
+	if location.PostArchived {
+		return fmt.Errorf("cannot reply to comment %s: %w", parentCommentID, domain.ErrPostArchived)
+	}
+	if location.PostLocked {
+		return fmt.Errorf("cannot reply to comment %s: %w", parentCommentID, domain.ErrPostLocked)
+	}
 
 	author, err := app.repo.GetUserByID(ctx, reply.Author)
 	if err != nil {
@@ -65,72 +62,27 @@ func (app *App) ReplyToComment(ctx context.Context, parentCommentID string, repl
 
 	reply.AvatarLink = author.ImageURL
 
-	// 2. Проверяем активность поста
-	app.Lock()
-	defer app.Unlock()
-
-	if _, ok := app.timers[parentPost.ID]; !ok {
-		return errors.New("cannot comment on archived post")
-	}
-
-	// 3. Устанавливаем родителя для ответа
+	// 2. Устанавливаем родителя для ответа
 	reply.ParentID = parentCommentID
-	reply.CreatedAt = time.Now()
-
-	// 4. Обновляем таймер активности поста (аналогично AddComment)
-	app.resetPostTimer(parentPost.ID)
-
-	// 5. Добавляем комментарий в хранилище
-	if err := app.repo.AddComment(ctx, parentPost.ID, reply); err != nil {
-		return fmt.Errorf("failed to add reply: %w", err)
-	}
+	reply.CreatedAt = app.now()
 
-	return nil
-}
-
-// Вспомогательный метод для поиска поста по ID комментария
-func (app *App) findPostByCommentID(ctx context.Context, commentID string) (*domain.Post, error) {
-	posts, err := app.repo.GetPosts(ctx)
+	// 3. Перед записью убеждаемся, что тред всё ещё открыт
+	expiry, err := app.activePostExpiry(ctx, location.PostID)
 	if err != nil {
-		return nil, err
-	}
-
-	for _, post := range posts {
-		if containsComment(post.Comments, commentID) {
-			return &post, nil
+		if errors.Is(err, domain.ErrPostArchived) || errors.Is(err, domain.ErrPostLocked) {
+			return fmt.Errorf("cannot comment on post %s: %w", location.PostID, err)
 		}
+		return fmt.Errorf("get post expiry: %w", err)
 	}
 
-	return nil, errors.New("comment not found in any post")
-}
-
-// Рекурсивный поиск комментария
-func containsComment(comments []domain.Comment, targetID string) bool {
-	for _, comment := range comments {
-		if comment.ID == targetID {
-			return true
-		}
-		if containsComment(comment.Replies, targetID) {
-			return true
-		}
+	// 4. Добавляем комментарий и продлеваем жизнь поста (аналогично AddComment)
+	err = app.saveComment(ctx, expiry, reply)
+	if errors.Is(err, domain.ErrPostArchived) {
+		return fmt.Errorf("cannot comment on post %s: %w", location.PostID, err)
 	}
-	return false
-}
-
-// Обновление таймера поста (вынесено в отдельный метод для reuse)
-func (app *App) resetPostTimer(postID string) {
-	if timer, exists := app.timers[postID]; exists {
-		timer.Stop()
+	if err != nil {
+		return fmt.Errorf("failed to add reply: %w", err)
 	}
 
-	app.timers[postID] = time.AfterFunc(15*time.Minute, func() {
-		app.Lock()
-		defer app.Unlock()
-
-		if timer, ok := app.timers[postID]; ok {
-			delete(app.timers, postID)
-			go app.archivePost(context.Background(), postID)
-			timer.Stop()
-		}
-	})
+	return nil
 }
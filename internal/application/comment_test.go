@@ -0,0 +1,205 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"1337b04rd/internal/domain"
+	"1337b04rd/internal/ports/right"
+)
+
+type commentRepo struct {
+	right.DbPort
+	locations map[string]domain.CommentLocation
+}
+
+func (r *commentRepo) GetCommentLocation(ctx context.Context, commentID string) (*domain.CommentLocation, error) {
+	location, ok := r.locations[commentID]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &location, nil
+}
+
+// threadRepo — фейковое хранилище треда с комментариями поверх expiryRepo
+type threadRepo struct {
+	*expiryRepo
+	locations map[string]domain.CommentLocation
+	comments  map[string][]domain.Comment
+	addErr    error
+}
+
+func newThreadRepo(posts ...domain.PostExpiry) *threadRepo {
+	return &threadRepo{
+		expiryRepo: newExpiryRepo(posts...),
+		locations:  make(map[string]domain.CommentLocation),
+		comments:   make(map[string][]domain.Comment),
+	}
+}
+
+func (r *threadRepo) GetPostByID(ctx context.Context, id string) (*domain.Post, error) {
+	if _, err := r.GetPostExpiry(ctx, id); err != nil {
+		return nil, err
+	}
+	return &domain.Post{ID: id}, nil
+}
+
+func (r *threadRepo) GetUserByID(ctx context.Context, userID string) (*domain.User, error) {
+	return &domain.User{ID: userID}, nil
+}
+
+func (r *threadRepo) GetCommentLocation(ctx context.Context, commentID string) (*domain.CommentLocation, error) {
+	location, ok := r.locations[commentID]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &location, nil
+}
+
+func (r *threadRepo) AddComment(ctx context.Context, postID string, comment *domain.Comment, now, expiresAt time.Time) error {
+	if r.addErr != nil {
+		return r.addErr
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.extend(postID, now, expiresAt); err != nil {
+		return err
+	}
+	r.comments[postID] = append(r.comments[postID], *comment)
+	return nil
+}
+
+func TestAddComment_FailedInsertDoesNotBump(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	policy := DefaultLifetimePolicy()
+	policy.Clock = clock
+	expiresAt := clock.now.Add(10 * time.Minute)
+	repo := newThreadRepo(domain.PostExpiry{PostID: "post", CreatedAt: clock.now, ExpiresAt: expiresAt})
+	a := NewApp(repo, nil, nil, userService{}, policy, DefaultImagePolicy(), time.Hour)
+	ctx := context.Background()
+
+	repo.addErr = errors.New("insert failed")
+	if err := a.AddComment(ctx, "post", &domain.Comment{ID: "c1"}); err == nil {
+		t.Fatal("expected insert error")
+	}
+	repo.locations["parent"] = domain.CommentLocation{CommentID: "parent", PostID: "post"}
+	if err := a.ReplyToComment(ctx, "post", "parent", &domain.Comment{ID: "c2"}); err == nil {
+		t.Fatal("expected insert error")
+	}
+
+	expiry, _ := repo.GetPostExpiry(ctx, "post")
+	if !expiry.ExpiresAt.Equal(expiresAt) || expiry.Comments != 0 {
+		t.Fatalf("failed inserts must not bump the thread, got expiry %v and %d comments", expiry.ExpiresAt, expiry.Comments)
+	}
+
+	repo.addErr = nil
+	if err := a.AddComment(ctx, "post", &domain.Comment{ID: "c3"}); err != nil {
+		t.Fatalf("AddComment: %v", err)
+	}
+	expiry, _ = repo.GetPostExpiry(ctx, "post")
+	if !expiry.ExpiresAt.After(expiresAt) || expiry.Comments != 1 {
+		t.Fatalf("saved comment must bump the thread, got expiry %v and %d comments", expiry.ExpiresAt, expiry.Comments)
+	}
+}
+
+func TestReplyToComment_ParentLookup(t *testing.T) {
+	repo := &commentRepo{locations: map[string]domain.CommentLocation{
+		"archived-comment": {CommentID: "archived-comment", PostID: "old-post", PostArchived: true},
+		"locked-comment":   {CommentID: "locked-comment", PostID: "locked-post", PostLocked: true},
+	}}
+	a := NewApp(repo, nil, nil, userService{}, DefaultLifetimePolicy(), DefaultImagePolicy(), time.Hour)
+
+	err := a.ReplyToComment(context.Background(), "old-post", "archived-comment", &domain.Comment{ID: "reply"})
+	if !errors.Is(err, domain.ErrPostArchived) {
+		t.Fatalf("expected ErrPostArchived, got %v", err)
+	}
+
+	err = a.ReplyToComment(context.Background(), "locked-post", "locked-comment", &domain.Comment{ID: "reply"})
+	if !errors.Is(err, domain.ErrPostLocked) {
+		t.Fatalf("expected ErrPostLocked, got %v", err)
+	}
+
+	err = a.ReplyToComment(context.Background(), "old-post", "missing", &domain.Comment{ID: "reply"})
+	if !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestReplyToComment_ParentFromAnotherPost(t *testing.T) {
+	now := time.Now()
+	repo := newThreadRepo(
+		domain.PostExpiry{PostID: "a", CreatedAt: now, ExpiresAt: now.Add(time.Hour)},
+		domain.PostExpiry{PostID: "b", CreatedAt: now, ExpiresAt: now.Add(time.Hour)},
+	)
+	repo.locations["comment-b"] = domain.CommentLocation{CommentID: "comment-b", PostID: "b"}
+	a := NewApp(repo, nil, nil, userService{}, DefaultLifetimePolicy(), DefaultImagePolicy(), time.Hour)
+	ctx := context.Background()
+
+	err := a.ReplyToComment(ctx, "a", "comment-b", &domain.Comment{ID: "reply"})
+	if !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if len(repo.comments["a"]) != 0 || len(repo.comments["b"]) != 0 {
+		t.Fatalf("reply must not be stored, got %v", repo.comments)
+	}
+	if expiry, _ := repo.GetPostExpiry(ctx, "b"); expiry.Comments != 0 {
+		t.Fatalf("other thread must not be bumped, got %d comments", expiry.Comments)
+	}
+
+	if err := a.ReplyToComment(ctx, "b", "comment-b", &domain.Comment{ID: "reply"}); err != nil {
+		t.Fatalf("ReplyToComment: %v", err)
+	}
+	if len(repo.comments["b"]) != 1 {
+		t.Fatalf("expected reply in thread b, got %v", repo.comments)
+	}
+}
+
+func TestAddComment_PinnedPostAfterTTL(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	policy := DefaultLifetimePolicy()
+	policy.Clock = clock
+	repo := newThreadRepo(domain.PostExpiry{PostID: "post", CreatedAt: clock.now, ExpiresAt: clock.now.Add(policy.TTL), Pinned: true})
+	repo.locations["parent"] = domain.CommentLocation{CommentID: "parent", PostID: "post"}
+	a := NewApp(repo, nil, nil, userService{}, policy, DefaultImagePolicy(), time.Hour)
+	ctx := context.Background()
+
+	// Ни срок жизни, ни MaxLifetime не закрывают закреплённый тред
+	clock.Advance(policy.MaxLifetime + policy.TTL)
+
+	if err := a.AddComment(ctx, "post", &domain.Comment{ID: "c1"}); err != nil {
+		t.Fatalf("AddComment: %v", err)
+	}
+	if err := a.ReplyToComment(ctx, "post", "parent", &domain.Comment{ID: "c2"}); err != nil {
+		t.Fatalf("ReplyToComment: %v", err)
+	}
+	if expiry, _ := repo.GetPostExpiry(ctx, "post"); expiry.Comments != 2 {
+		t.Fatalf("pinned thread was not bumped: %d comments", expiry.Comments)
+	}
+}
+
+func TestAddComment_ExpiredPostNotYetArchived(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	policy := DefaultLifetimePolicy()
+	policy.Clock = clock
+	repo := newThreadRepo(domain.PostExpiry{PostID: "post", CreatedAt: clock.now, ExpiresAt: clock.now.Add(policy.TTL)})
+	repo.locations["parent"] = domain.CommentLocation{CommentID: "parent", PostID: "post"}
+	a := NewApp(repo, nil, nil, userService{}, policy, DefaultImagePolicy(), time.Hour)
+	ctx := context.Background()
+
+	// Срок вышел, но архивация до треда ещё не дошла
+	clock.Advance(policy.TTL)
+
+	if err := a.AddComment(ctx, "post", &domain.Comment{ID: "c1"}); !errors.Is(err, domain.ErrPostArchived) {
+		t.Fatalf("AddComment: %v, want ErrPostArchived", err)
+	}
+	if err := a.ReplyToComment(ctx, "post", "parent", &domain.Comment{ID: "c2"}); !errors.Is(err, domain.ErrPostArchived) {
+		t.Fatalf("ReplyToComment: %v, want ErrPostArchived", err)
+	}
+	if len(repo.comments["post"]) != 0 {
+		t.Fatalf("comments stored in an expired thread: %v", repo.comments["post"])
+	}
+}
@@ -0,0 +1,237 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"1337b04rd/internal/domain"
+)
+
+const expiryJobTimeout = 10 * time.Second
+
+// StartExpiryScheduler восстанавливает расписание архивации из таблицы Post:
+// просроченные посты архивируются сразу, для остальных заводятся таймеры.
+// Затем каждые interval повторяет синхронизацию, чтобы подхватывать посты
+// других реплик и архивировать их, если реплика-владелец таймера упала.
+func (app *App) StartExpiryScheduler(ctx context.Context, interval time.Duration) error {
+	if err := app.syncExpirySchedule(ctx); err != nil {
+		return fmt.Errorf("restore expiry schedule: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	app.Lock()
+	app.stopScheduler = cancel
+	app.jobs.Add(1)
+	app.Unlock()
+
+	go func() {
+		defer app.jobs.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := app.syncExpirySchedule(ctx); err != nil {
+					fmt.Printf("Failed to sync expiry schedule: %v\n", err)
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// StopExpiryScheduler останавливает периодическую синхронизацию и все таймеры,
+// дожидается уже запущенных архиваций и напоследок архивирует просроченные посты.
+// Сроки жизни остальных постов уже лежат в БД и будут подхвачены при следующем запуске.
+func (app *App) StopExpiryScheduler(ctx context.Context) error {
+	app.Lock()
+	app.stopped = true
+	if app.stopScheduler != nil {
+		app.stopScheduler()
+	}
+	for id := range app.timers {
+		app.unschedulePost(id)
+	}
+	app.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		app.jobs.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return fmt.Errorf("wait for archive jobs: %w", ctx.Err())
+	}
+
+	archived, err := app.repo.ArchiveExpiredPosts(ctx, app.now())
+	if err != nil {
+		return fmt.Errorf("archive expired posts: %w", err)
+	}
+	for _, id := range archived {
+		fmt.Printf("Successfully archived post %s\n", id)
+	}
+	return nil
+}
+
+func (app *App) syncExpirySchedule(ctx context.Context) error {
+	syncCtx, cancel := context.WithTimeout(ctx, expiryJobTimeout)
+	defer cancel()
+
+	archived, err := app.repo.ArchiveExpiredPosts(syncCtx, app.now())
+	if err != nil {
+		return fmt.Errorf("archive expired posts: %w", err)
+	}
+	for _, id := range archived {
+		fmt.Printf("Successfully archived post %s\n", id)
+	}
+
+	expiries, err := app.repo.ListPostExpiries(syncCtx)
+	if err != nil {
+		return fmt.Errorf("list post expiries: %w", err)
+	}
+
+	app.Lock()
+	defer app.Unlock()
+
+	if app.stopped {
+		return nil
+	}
+
+	active := make(map[string]struct{}, len(expiries))
+	for _, expiry := range expiries {
+		active[expiry.PostID] = struct{}{}
+		app.schedulePost(expiry.PostID, expiry.ExpiresAt)
+	}
+
+	// Посты, которые заархивировала другая реплика, больше не нужно отслеживать
+	for id := range app.timers {
+		if _, ok := active[id]; !ok {
+			app.unschedulePost(id)
+		}
+	}
+
+	return nil
+}
+
+// activePostExpiry возвращает срок жизни треда, если в него ещё можно писать.
+// Истёкший тред отклоняется, даже если фоновая архивация до него ещё не дошла;
+// закреплённый тред не истекает.
+func (app *App) activePostExpiry(ctx context.Context, postID string) (*domain.PostExpiry, error) {
+	expiry, err := app.repo.GetPostExpiry(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if expiry.Archived || (!expiry.Pinned && !expiry.ExpiresAt.After(app.now())) {
+		return nil, domain.ErrPostArchived
+	}
+	if expiry.Locked {
+		return nil, domain.ErrPostLocked
+	}
+	return expiry, nil
+}
+
+// saveComment сохраняет комментарий и продлевает жизнь треда по правилам LifetimePolicy.
+// Хранилище делает и то и другое в одной транзакции, заново проверяя срок треда,
+// поэтому в истёкший тред комментарий не попадёт, а неудачная запись его не продлит.
+func (app *App) saveComment(ctx context.Context, expiry *domain.PostExpiry, comment *domain.Comment) error {
+	now := app.now()
+	expiresAt, _ := app.lifetime.ExpiryAfterComment(*expiry, now)
+
+	if err := app.repo.AddComment(ctx, expiry.PostID, comment, now, expiresAt); err != nil {
+		return err
+	}
+
+	// Закреплённый тред не архивируется, таймер ему не нужен
+	if expiry.Pinned {
+		return nil
+	}
+
+	app.Lock()
+	defer app.Unlock()
+	app.schedulePost(expiry.PostID, expiresAt)
+
+	return nil
+}
+
+// schedulePost заводит (или переносит) таймер архивации поста. Вызывать под app.Lock().
+func (app *App) schedulePost(postID string, expiresAt time.Time) {
+	if app.stopped {
+		return
+	}
+	if deadline, ok := app.deadlines[postID]; ok && !expiresAt.After(deadline) {
+		return
+	}
+
+	if timer, ok := app.timers[postID]; ok {
+		timer.Stop()
+	}
+
+	app.deadlines[postID] = expiresAt
+	app.timers[postID] = time.AfterFunc(expiresAt.Sub(app.now()), func() {
+		app.Lock()
+		// Таймер мог быть перенесён, пока ждал блокировку
+		if deadline, ok := app.deadlines[postID]; !ok || !deadline.Equal(expiresAt) || app.stopped {
+			app.Unlock()
+			return
+		}
+		app.unschedulePost(postID)
+		app.jobs.Add(1)
+		app.Unlock()
+
+		defer app.jobs.Done()
+		app.expirePost(postID)
+	})
+}
+
+// unschedulePost останавливает таймер поста. Вызывать под app.Lock().
+func (app *App) unschedulePost(postID string) {
+	if timer, ok := app.timers[postID]; ok {
+		timer.Stop()
+	}
+	delete(app.timers, postID)
+	delete(app.deadlines, postID)
+}
+
+// expirePost архивирует пост, если его срок истёк и в БД. Если тред тем временем
+// продлили на другой реплике, таймер переназначается на новый срок.
+func (app *App) expirePost(postID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), expiryJobTimeout)
+	defer cancel()
+
+	archived, err := app.repo.ArchiveExpiredPost(ctx, postID, app.now())
+	if err != nil {
+		// Периодическая синхронизация повторит попытку
+		fmt.Printf("Failed to archive post %s: %v\n", postID, err)
+		return
+	}
+	if archived {
+		fmt.Printf("Successfully archived post %s\n", postID)
+		return
+	}
+
+	expiry, err := app.repo.GetPostExpiry(ctx, postID)
+	if err != nil {
+		if !errors.Is(err, domain.ErrNotFound) {
+			fmt.Printf("Failed to reload expiry of post %s: %v\n", postID, err)
+		}
+		return
+	}
+	if expiry.Archived || expiry.Pinned {
+		return
+	}
+
+	app.Lock()
+	defer app.Unlock()
+	app.schedulePost(postID, expiry.ExpiresAt)
+}
@@ -0,0 +1,185 @@
+package application
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"1337b04rd/internal/domain"
+	"1337b04rd/internal/ports/right"
+)
+
+// expiryRepo — фейковое хранилище сроков жизни постов
+type expiryRepo struct {
+	right.DbPort
+	mu       sync.Mutex
+	posts    map[string]*domain.PostExpiry
+	archived chan string
+}
+
+func newExpiryRepo(posts ...domain.PostExpiry) *expiryRepo {
+	r := &expiryRepo{
+		posts:    make(map[string]*domain.PostExpiry),
+		archived: make(chan string, len(posts)+1),
+	}
+	for i := range posts {
+		r.posts[posts[i].PostID] = &posts[i]
+	}
+	return r
+}
+
+func (r *expiryRepo) archive(id string, now time.Time) bool {
+	p, ok := r.posts[id]
+	if !ok || p.Archived || p.Pinned || p.ExpiresAt.After(now) {
+		return false
+	}
+	p.Archived = true
+	r.archived <- id
+	return true
+}
+
+func (r *expiryRepo) ArchiveExpiredPosts(ctx context.Context, now time.Time) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var ids []string
+	for id := range r.posts {
+		if r.archive(id, now) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (r *expiryRepo) ArchiveExpiredPost(ctx context.Context, id string, now time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.archive(id, now), nil
+}
+
+func (r *expiryRepo) ListPostExpiries(ctx context.Context) ([]domain.PostExpiry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var list []domain.PostExpiry
+	for _, p := range r.posts {
+		if !p.Archived && !p.Pinned {
+			list = append(list, *p)
+		}
+	}
+	return list, nil
+}
+
+func (r *expiryRepo) GetPostExpiry(ctx context.Context, id string) (*domain.PostExpiry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.posts[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	cp := *p
+	return &cp, nil
+}
+
+// extend повторяет условие атомарного продления в хранилище. Вызывать под r.mu.
+func (r *expiryRepo) extend(id string, now, expiresAt time.Time) error {
+	p, ok := r.posts[id]
+	if !ok || p.Archived || (!p.Pinned && !p.ExpiresAt.After(now)) {
+		return domain.ErrPostArchived
+	}
+	if expiresAt.After(p.ExpiresAt) {
+		p.ExpiresAt = expiresAt
+	}
+	p.Comments++
+	return nil
+}
+
+func TestStartExpiryScheduler_RestoresFromRepo(t *testing.T) {
+	now := time.Now()
+	repo := newExpiryRepo(
+		domain.PostExpiry{PostID: "overdue", ExpiresAt: now.Add(-time.Minute)},
+		domain.PostExpiry{PostID: "soon", ExpiresAt: now.Add(50 * time.Millisecond)},
+		domain.PostExpiry{PostID: "later", ExpiresAt: now.Add(time.Hour)},
+	)
+	a := NewApp(repo, nil, nil, userService{}, DefaultLifetimePolicy(), DefaultImagePolicy(), time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := a.StartExpiryScheduler(ctx, time.Minute); err != nil {
+		t.Fatalf("start scheduler: %v", err)
+	}
+
+	if id := <-repo.archived; id != "overdue" {
+		t.Fatalf("expected overdue post archived on startup, got %s", id)
+	}
+
+	a.Lock()
+	_, soon := a.Timers()["soon"]
+	_, later := a.Timers()["later"]
+	_, overdue := a.Timers()["overdue"]
+	a.Unlock()
+	if !soon || !later || overdue {
+		t.Fatalf("unexpected timers: soon=%v later=%v overdue=%v", soon, later, overdue)
+	}
+
+	select {
+	case id := <-repo.archived:
+		if id != "soon" {
+			t.Fatalf("expected post soon archived by timer, got %s", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timer did not archive post")
+	}
+}
+
+func TestExpirePost_ReschedulesWhenExtendedElsewhere(t *testing.T) {
+	extended := time.Now().Add(time.Hour)
+	repo := newExpiryRepo(domain.PostExpiry{PostID: "post", ExpiresAt: extended})
+	a := NewApp(repo, nil, nil, userService{}, DefaultLifetimePolicy(), DefaultImagePolicy(), time.Hour)
+
+	// Таймер этой реплики сработал по старому сроку, а в БД срок уже продлён
+	a.expirePost("post")
+
+	a.Lock()
+	deadline, ok := a.deadlines["post"]
+	a.Unlock()
+	if !ok || !deadline.Equal(extended) {
+		t.Fatalf("expected timer rescheduled to %v, got %v (ok=%v)", extended, deadline, ok)
+	}
+}
+
+func TestStopExpiryScheduler_StopsTimersAndFlushes(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	policy := DefaultLifetimePolicy()
+	policy.Clock = clock
+
+	repo := newExpiryRepo(domain.PostExpiry{PostID: "post", ExpiresAt: clock.Now().Add(time.Hour)})
+	a := NewApp(repo, nil, nil, userService{}, policy, DefaultImagePolicy(), time.Hour)
+
+	if err := a.StartExpiryScheduler(context.Background(), time.Minute); err != nil {
+		t.Fatalf("start scheduler: %v", err)
+	}
+
+	// Срок истёк к моменту остановки, но таймер ещё не сработал
+	clock.Advance(2 * time.Hour)
+	if err := a.StopExpiryScheduler(context.Background()); err != nil {
+		t.Fatalf("stop scheduler: %v", err)
+	}
+
+	if id := <-repo.archived; id != "post" {
+		t.Fatalf("expected post archived on shutdown, got %s", id)
+	}
+
+	a.Lock()
+	defer a.Unlock()
+	if len(a.Timers()) != 0 {
+		t.Fatalf("expected no timers after stop, got %d", len(a.Timers()))
+	}
+	a.schedulePost("new", clock.Now().Add(time.Minute))
+	if len(a.Timers()) != 0 {
+		t.Fatal("scheduler accepted a timer after stop")
+	}
+}
@@ -0,0 +1,130 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"1337b04rd/internal/domain"
+)
+
+const imageGCTimeout = 5 * time.Minute
+
+// StartImageGC каждые interval удаляет просроченные архивные треды и объекты хранилища,
+// на которые больше ничего не ссылается. Останавливается вместе с ctx.
+func (app *App) StartImageGC(ctx context.Context, interval time.Duration) {
+	app.background.Add(1)
+	go func() {
+		defer app.background.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				gcCtx, cancel := context.WithTimeout(ctx, imageGCTimeout)
+				deleted, err := app.CollectImageGarbage(gcCtx)
+				cancel()
+				if err != nil {
+					fmt.Printf("Failed to collect image garbage: %v\n", err)
+					continue
+				}
+				if deleted > 0 {
+					fmt.Printf("Deleted %d unreferenced images\n", deleted)
+				}
+			}
+		}
+	}()
+}
+
+// CollectImageGarbage — один проход mark-and-sweep. Сначала удаляются архивные треды
+// старше ArchiveRetention, затем из хранилища — объекты, на которые не ссылается
+// ни один пост или комментарий. Объекты моложе GCGrace не трогаются: их могли загрузить
+// для поста, который ещё не сохранён. Возвращает число удалённых объектов.
+func (app *App) CollectImageGarbage(ctx context.Context) (int, error) {
+	if retention := app.lifetime.ArchiveRetention; retention > 0 {
+		purged, err := app.repo.PurgeArchivedPosts(ctx, app.now().Add(-retention))
+		if err != nil {
+			return 0, fmt.Errorf("purge archived posts: %w", err)
+		}
+		for _, id := range purged {
+			fmt.Printf("Purged archived post %s\n", id)
+		}
+	}
+
+	// Объекты перечисляются до чтения ссылок: всё, что сохранено в БД позже,
+	// загружено недавно и защищено GCGrace
+	objects, err := app.imageStorage.ListImages(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("list images: %w", err)
+	}
+	live, err := app.liveImages(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, obj := range objects {
+		if _, ok := live[obj.Name]; ok || app.isRecent(obj.ModTime) {
+			continue
+		}
+		// Объект могли перезаписать для нового поста уже после перечисления
+		stale, err := app.isStale(ctx, obj.Name)
+		if err != nil {
+			fmt.Printf("Failed to check image %s: %v\n", obj.Name, err)
+			continue
+		}
+		if !stale {
+			continue
+		}
+		if err := app.imageStorage.DeleteImage(ctx, obj.Name); err != nil {
+			fmt.Printf("Failed to delete unreferenced image %s: %v\n", obj.Name, err)
+			continue
+		}
+		deleted++
+	}
+	return deleted, ctx.Err()
+}
+
+// liveImages помечает объекты, на которые ссылаются посты и вложения, вместе со всеми
+// превью настроенных размеров: в БД хранится только первое из них
+func (app *App) liveImages(ctx context.Context) (map[string]struct{}, error) {
+	urls, err := app.repo.ListImageURLs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list image references: %w", err)
+	}
+
+	live := make(map[string]struct{}, len(urls)*(1+len(app.images.ThumbnailSizes)))
+	for _, url := range urls {
+		name, ok := domain.ImageObjectName(url)
+		if !ok {
+			continue
+		}
+		live[name] = struct{}{}
+		for _, size := range app.images.ThumbnailSizes {
+			live[thumbnailName(name, size)] = struct{}{}
+		}
+	}
+	return live, nil
+}
+
+func (app *App) isRecent(modTime time.Time) bool {
+	return app.now().Sub(modTime) < app.images.GCGrace
+}
+
+// isStale перечитывает время изменения объекта; удалённый объект удалять уже не нужно
+func (app *App) isStale(ctx context.Context, objectName string) (bool, error) {
+	obj, err := app.imageStorage.GetImage(ctx, objectName)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	obj.Body.Close()
+	return !app.isRecent(obj.ModTime), nil
+}
@@ -0,0 +1,113 @@
+package application
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"1337b04rd/internal/domain"
+	"1337b04rd/internal/ports/right"
+)
+
+type imageRepo struct {
+	right.DbPort
+	urls   []string
+	purged time.Time
+}
+
+func (r *imageRepo) ListImageURLs(ctx context.Context) ([]string, error) {
+	return r.urls, nil
+}
+
+func (r *imageRepo) PurgeArchivedPosts(ctx context.Context, before time.Time) ([]string, error) {
+	r.purged = before
+	return nil, nil
+}
+
+func TestCollectImageGarbage(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	lifetime := DefaultLifetimePolicy()
+	lifetime.Clock = clock
+	lifetime.ArchiveRetention = 24 * time.Hour
+
+	policy := DefaultImagePolicy()
+	policy.ThumbnailSizes = []int{200, 400}
+	policy.GCGrace = time.Hour
+
+	storage := newMemoryStorage(clock)
+	repo := &imageRepo{urls: []string{
+		domain.ImageURL("used.png"),
+		domain.ImageURL("used_200.jpg"),
+		"https://rickandmortyapi.com/api/character/avatar/1.jpeg",
+	}}
+	a := NewApp(repo, nil, storage, userService{}, lifetime, policy, time.Hour)
+
+	ctx := context.Background()
+	for _, name := range []string{"used.png", "used_200.jpg", "used_400.jpg", "orphan.png", "orphan_200.jpg"} {
+		storage.UploadImage(ctx, name, strings.NewReader("x"), 1, "image/png")
+	}
+	clock.Advance(2 * time.Hour)
+	// Загружен для поста, который ещё не сохранён
+	storage.UploadImage(ctx, "pending.png", strings.NewReader("x"), 1, "image/png")
+
+	deleted, err := a.CollectImageGarbage(ctx)
+	if err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("deleted = %d, want 2", deleted)
+	}
+	for _, name := range []string{"used.png", "used_200.jpg", "used_400.jpg", "pending.png"} {
+		if _, ok := storage.objects[name]; !ok {
+			t.Errorf("%s was deleted", name)
+		}
+	}
+	for _, name := range []string{"orphan.png", "orphan_200.jpg"} {
+		if _, ok := storage.objects[name]; ok {
+			t.Errorf("%s was kept", name)
+		}
+	}
+	if want := clock.Now().Add(-lifetime.ArchiveRetention); !repo.purged.Equal(want) {
+		t.Errorf("purged archive before %v, want %v", repo.purged, want)
+	}
+}
+
+// slowPurgeRepo держит проход сборки мусора, пока не отменят его контекст
+type slowPurgeRepo struct {
+	imageRepo
+	once     sync.Once
+	started  chan struct{}
+	finished bool
+}
+
+func (r *slowPurgeRepo) PurgeArchivedPosts(ctx context.Context, before time.Time) ([]string, error) {
+	// Тикер может сработать одновременно с отменой, и тогда проход будет не один
+	r.once.Do(func() { close(r.started) })
+	<-ctx.Done()
+	time.Sleep(10 * time.Millisecond)
+	r.finished = true
+	return nil, ctx.Err()
+}
+
+func TestWaitBackground_WaitsForImageGC(t *testing.T) {
+	lifetime := DefaultLifetimePolicy()
+	lifetime.ArchiveRetention = time.Hour
+	repo := &slowPurgeRepo{started: make(chan struct{})}
+	a := NewApp(repo, nil, newMemoryStorage(&fakeClock{now: time.Now()}), userService{}, lifetime, DefaultImagePolicy(), time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.StartImageGC(ctx, time.Millisecond)
+	<-repo.started
+	cancel()
+
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), time.Second)
+	defer waitCancel()
+	if err := a.WaitBackground(waitCtx); err != nil {
+		t.Fatalf("WaitBackground: %v", err)
+	}
+	if !repo.finished {
+		t.Fatal("WaitBackground returned while the GC pass was still running")
+	}
+}
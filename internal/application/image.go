@@ -0,0 +1,128 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"1337b04rd/internal/domain"
+	"1337b04rd/pkg/imaging"
+)
+
+// ImagePolicy — правила приёма загружаемых изображений
+type ImagePolicy struct {
+	Limits imaging.Limits
+	// ThumbnailSizes — стороны квадратов, в которые вписываются превью.
+	// Первый размер используется в каталоге и архиве.
+	ThumbnailSizes []int
+	// GCGrace — сколько сборщик мусора не трогает объект после загрузки,
+	// чтобы успел сохраниться пост, который на него ссылается
+	GCGrace time.Duration
+}
+
+func DefaultImagePolicy() ImagePolicy {
+	return ImagePolicy{
+		Limits:         imaging.DefaultLimits(),
+		ThumbnailSizes: []int{200},
+		GCGrace:        time.Hour,
+	}
+}
+
+// UploadImage проверяет, что r — настоящее изображение допустимого размера,
+// перекодирует его без метаданных и сохраняет в хранилище вместе с превью.
+// Тип и расширение определяются по содержимому, а не по данным клиента.
+// Имя объекта — SHA-256 перекодированных данных, поэтому повторная загрузка
+// того же изображения переиспользует уже сохранённый объект.
+func (app *App) UploadImage(ctx context.Context, r io.Reader) (*domain.Image, error) {
+	img, err := imaging.Sanitize(r, app.images.Limits)
+	if err != nil {
+		switch {
+		case errors.Is(err, imaging.ErrUnsupportedFormat):
+			return nil, fmt.Errorf("%w: %v", domain.ErrUnsupportedImage, err)
+		case errors.Is(err, imaging.ErrTooLarge), errors.Is(err, imaging.ErrCorrupt):
+			return nil, fmt.Errorf("%w: %v", domain.ErrInvalidImage, err)
+		default:
+			return nil, err
+		}
+	}
+
+	sum := sha256.Sum256(img.Data)
+	stored := &domain.Image{
+		ObjectName:  hex.EncodeToString(sum[:]) + img.Ext,
+		ContentType: img.ContentType,
+		Width:       img.Width,
+		Height:      img.Height,
+		Size:        int64(len(img.Data)),
+	}
+	if err := app.storeImage(ctx, stored.ObjectName, func() (*imaging.Image, error) { return img, nil }); err != nil {
+		return nil, err
+	}
+
+	// Объекты, сохранённые до ошибки, удалит сборщик мусора: они могут быть общими с другими постами
+	for _, size := range app.images.ThumbnailSizes {
+		name := thumbnailName(stored.ObjectName, size)
+		err := app.storeImage(ctx, name, func() (*imaging.Image, error) {
+			thumb, err := img.Thumbnail(size)
+			if err != nil {
+				return nil, fmt.Errorf("make %dpx thumbnail: %w", size, err)
+			}
+			return thumb, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		stored.Thumbnails = append(stored.Thumbnails, name)
+	}
+
+	return stored, nil
+}
+
+// storeImage сохраняет объект, если в хранилище нет его свежей копии.
+// encode вызывается только тогда, когда объект действительно нужно записать.
+func (app *App) storeImage(ctx context.Context, objectName string, encode func() (*imaging.Image, error)) error {
+	fresh, err := app.hasFreshImage(ctx, objectName)
+	if err != nil {
+		return err
+	}
+	if fresh {
+		return nil
+	}
+
+	img, err := encode()
+	if err != nil {
+		return err
+	}
+	if err := app.imageStorage.UploadImage(ctx, objectName, bytes.NewReader(img.Data), int64(len(img.Data)), img.ContentType); err != nil {
+		return fmt.Errorf("store image %s: %w", objectName, err)
+	}
+	return nil
+}
+
+// hasFreshImage сообщает, можно ли переиспользовать сохранённый объект. Объект старше
+// половины GCGrace перезаписывается: новое время изменения защищает его от сборщика мусора,
+// пока пост со ссылкой на него не сохранён в БД.
+func (app *App) hasFreshImage(ctx context.Context, objectName string) (bool, error) {
+	obj, err := app.imageStorage.GetImage(ctx, objectName)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("check image %s: %w", objectName, err)
+	}
+	obj.Body.Close()
+
+	return app.now().Sub(obj.ModTime) < app.images.GCGrace/2, nil
+}
+
+// thumbnailName кладёт превью рядом с оригиналом: <имя>_<размер>.jpg
+func thumbnailName(objectName string, size int) string {
+	base := strings.TrimSuffix(objectName, path.Ext(objectName))
+	return fmt.Sprintf("%s_%d.jpg", base, size)
+}
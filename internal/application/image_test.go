@@ -0,0 +1,169 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"1337b04rd/internal/domain"
+	"1337b04rd/internal/ports/right"
+)
+
+type memoryStorage struct {
+	right.ImageStorage
+	clock        Clock
+	objects      map[string][]byte
+	contentTypes map[string]string
+	modTimes     map[string]time.Time
+	uploads      int
+}
+
+func newMemoryStorage(clock Clock) *memoryStorage {
+	return &memoryStorage{
+		clock:        clock,
+		objects:      map[string][]byte{},
+		contentTypes: map[string]string{},
+		modTimes:     map[string]time.Time{},
+	}
+}
+
+func (s *memoryStorage) UploadImage(ctx context.Context, objectName string, r io.Reader, size int64, contentType string) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.objects[objectName] = data
+	s.contentTypes[objectName] = contentType
+	s.modTimes[objectName] = s.clock.Now()
+	s.uploads++
+	return nil
+}
+
+func (s *memoryStorage) GetImage(ctx context.Context, objectName string) (*right.ImageObject, error) {
+	data, ok := s.objects[objectName]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &right.ImageObject{
+		Body:        nopSeekCloser{bytes.NewReader(data)},
+		Size:        int64(len(data)),
+		ContentType: s.contentTypes[objectName],
+		ModTime:     s.modTimes[objectName],
+	}, nil
+}
+
+func (s *memoryStorage) DeleteImage(ctx context.Context, objectName string) error {
+	delete(s.objects, objectName)
+	delete(s.contentTypes, objectName)
+	delete(s.modTimes, objectName)
+	return nil
+}
+
+func (s *memoryStorage) ListImages(ctx context.Context) ([]right.ObjectInfo, error) {
+	var objects []right.ObjectInfo
+	for name, data := range s.objects {
+		objects = append(objects, right.ObjectInfo{Name: name, Size: int64(len(data)), ModTime: s.modTimes[name]})
+	}
+	return objects, nil
+}
+
+type nopSeekCloser struct {
+	io.ReadSeeker
+}
+
+func (nopSeekCloser) Close() error { return nil }
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestUploadImage(t *testing.T) {
+	storage := newMemoryStorage(systemClock{})
+	policy := DefaultImagePolicy()
+	policy.ThumbnailSizes = []int{50, 100}
+	a := NewApp(nil, nil, storage, userService{}, DefaultLifetimePolicy(), policy, time.Hour)
+
+	img, err := a.UploadImage(context.Background(), bytes.NewReader(encodePNG(t, 300, 150)))
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if !strings.HasSuffix(img.ObjectName, ".png") {
+		t.Errorf("object name %q has no .png extension", img.ObjectName)
+	}
+	if storage.contentTypes[img.ObjectName] != "image/png" {
+		t.Errorf("content type = %q", storage.contentTypes[img.ObjectName])
+	}
+
+	base := strings.TrimSuffix(img.ObjectName, ".png")
+	want := []string{base + "_50.jpg", base + "_100.jpg"}
+	if len(img.Thumbnails) != len(want) {
+		t.Fatalf("thumbnails = %v, want %v", img.Thumbnails, want)
+	}
+	for i, name := range want {
+		if img.Thumbnails[i] != name || storage.contentTypes[name] != "image/jpeg" {
+			t.Errorf("thumbnail %d = %q (%s), want %q", i, img.Thumbnails[i], storage.contentTypes[name], name)
+		}
+	}
+
+	// Тип определяется по содержимому, а не по данным клиента
+	_, err = a.UploadImage(context.Background(), strings.NewReader("<script>alert(1)</script>"))
+	if !errors.Is(err, domain.ErrUnsupportedImage) {
+		t.Errorf("err = %v, want domain.ErrUnsupportedImage", err)
+	}
+	if len(storage.objects) != 3 {
+		t.Errorf("storage has %d objects, want 3", len(storage.objects))
+	}
+}
+
+func TestUploadImage_Deduplicates(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	lifetime := DefaultLifetimePolicy()
+	lifetime.Clock = clock
+	storage := newMemoryStorage(clock)
+	policy := DefaultImagePolicy()
+	policy.GCGrace = time.Hour
+	a := NewApp(nil, nil, storage, userService{}, lifetime, policy, time.Hour)
+
+	data := encodePNG(t, 64, 64)
+	first, err := a.UploadImage(context.Background(), bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("first upload: %v", err)
+	}
+	if len(strings.TrimSuffix(first.ObjectName, ".png")) != 64 {
+		t.Errorf("object name %q is not a SHA-256 key", first.ObjectName)
+	}
+
+	second, err := a.UploadImage(context.Background(), bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("second upload: %v", err)
+	}
+	if second.ObjectName != first.ObjectName || second.Thumbnails[0] != first.Thumbnails[0] {
+		t.Errorf("second upload stored as %q, want %q", second.ObjectName, first.ObjectName)
+	}
+	if storage.uploads != 2 {
+		t.Errorf("storage received %d uploads, want 2 (original and thumbnail)", storage.uploads)
+	}
+
+	// Старый объект перезаписывается, чтобы сборщик мусора не удалил его до сохранения поста
+	clock.Advance(policy.GCGrace)
+	if _, err := a.UploadImage(context.Background(), bytes.NewReader(data)); err != nil {
+		t.Fatalf("third upload: %v", err)
+	}
+	if storage.uploads != 4 {
+		t.Errorf("storage received %d uploads, want 4", storage.uploads)
+	}
+	if got := storage.modTimes[first.ObjectName]; !got.Equal(clock.Now()) {
+		t.Errorf("modtime = %v, want refreshed to %v", got, clock.Now())
+	}
+}
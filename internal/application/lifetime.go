@@ -0,0 +1,92 @@
+package application
+
+import (
+	"errors"
+	"time"
+
+	"1337b04rd/internal/domain"
+)
+
+// Clock — источник текущего времени, в тестах подменяется фейком
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// LifetimePolicy описывает, сколько живёт тред до архивации
+type LifetimePolicy struct {
+	// TTL — время жизни поста без комментариев, отсчитывается от создания
+	TTL time.Duration
+	// CommentTTL — время жизни после последнего комментария
+	CommentTTL time.Duration
+	// MaxLifetime ограничивает общую жизнь треда от создания (0 — без ограничения)
+	MaxLifetime time.Duration
+	// BumpLimit — после стольких комментариев тред перестаёт продлеваться (0 — без ограничения)
+	BumpLimit int
+	// ArchiveRetention — сколько тред хранится в архиве до удаления (0 — бессрочно)
+	ArchiveRetention time.Duration
+	// Clock по умолчанию — системные часы
+	Clock Clock
+}
+
+// DefaultLifetimePolicy — правила из ТЗ: 10 минут без комментариев, 15 минут после последнего
+func DefaultLifetimePolicy() LifetimePolicy {
+	return LifetimePolicy{
+		TTL:        10 * time.Minute,
+		CommentTTL: 15 * time.Minute,
+	}
+}
+
+func (p LifetimePolicy) Validate() error {
+	if p.TTL <= 0 {
+		return errors.New("thread TTL must be positive")
+	}
+	if p.CommentTTL <= 0 {
+		return errors.New("thread comment TTL must be positive")
+	}
+	if p.MaxLifetime < 0 {
+		return errors.New("thread max lifetime must not be negative")
+	}
+	if p.BumpLimit < 0 {
+		return errors.New("thread bump limit must not be negative")
+	}
+	if p.ArchiveRetention < 0 {
+		return errors.New("archive retention must not be negative")
+	}
+	return nil
+}
+
+func (p LifetimePolicy) Now() time.Time {
+	if p.Clock == nil {
+		return systemClock{}.Now()
+	}
+	return p.Clock.Now()
+}
+
+// InitialExpiry возвращает срок жизни только что созданного поста
+func (p LifetimePolicy) InitialExpiry(createdAt time.Time) time.Time {
+	return p.capped(createdAt, createdAt.Add(p.TTL))
+}
+
+// ExpiryAfterComment возвращает новый срок жизни треда после комментария в момент at.
+// Второе значение false, если комментарий тред не продлевает (достигнут бамп-лимит).
+func (p LifetimePolicy) ExpiryAfterComment(expiry domain.PostExpiry, at time.Time) (time.Time, bool) {
+	if p.BumpLimit > 0 && expiry.Comments >= p.BumpLimit {
+		return expiry.ExpiresAt, false
+	}
+	return p.capped(expiry.CreatedAt, at.Add(p.CommentTTL)), true
+}
+
+func (p LifetimePolicy) capped(createdAt, expiresAt time.Time) time.Time {
+	if p.MaxLifetime > 0 {
+		if limit := createdAt.Add(p.MaxLifetime); expiresAt.After(limit) {
+			return limit
+		}
+	}
+	return expiresAt
+}
@@ -0,0 +1,111 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"1337b04rd/internal/domain"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestLifetimePolicy_ExpiryAfterComment(t *testing.T) {
+	created := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		policy   LifetimePolicy
+		comments int
+		at       time.Time
+		want     time.Time
+		bumped   bool
+	}{
+		{
+			name:   "comment extends thread",
+			policy: DefaultLifetimePolicy(),
+			at:     created.Add(5 * time.Minute),
+			want:   created.Add(20 * time.Minute),
+			bumped: true,
+		},
+		{
+			name:   "max lifetime caps extension",
+			policy: LifetimePolicy{TTL: 10 * time.Minute, CommentTTL: 15 * time.Minute, MaxLifetime: 12 * time.Minute},
+			at:     created.Add(5 * time.Minute),
+			want:   created.Add(12 * time.Minute),
+			bumped: true,
+		},
+		{
+			name:     "bump limit stops extension",
+			policy:   LifetimePolicy{TTL: 10 * time.Minute, CommentTTL: 15 * time.Minute, BumpLimit: 3},
+			comments: 3,
+			at:       created.Add(5 * time.Minute),
+			want:     created.Add(10 * time.Minute),
+			bumped:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expiry := domain.PostExpiry{
+				CreatedAt: created,
+				ExpiresAt: tt.policy.InitialExpiry(created),
+				Comments:  tt.comments,
+			}
+
+			got, bumped := tt.policy.ExpiryAfterComment(expiry, tt.at)
+			if !got.Equal(tt.want) || bumped != tt.bumped {
+				t.Fatalf("got (%v, %v), want (%v, %v)", got, bumped, tt.want, tt.bumped)
+			}
+		})
+	}
+}
+
+func TestAddComment_UsesPolicyClock(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	policy := DefaultLifetimePolicy()
+	policy.Clock = clock
+
+	repo := newThreadRepo(domain.PostExpiry{
+		PostID:    "post",
+		CreatedAt: clock.Now(),
+		ExpiresAt: policy.InitialExpiry(clock.Now()),
+	})
+	a := NewApp(repo, nil, nil, userService{}, policy, DefaultImagePolicy(), time.Hour)
+
+	clock.Advance(9 * time.Minute)
+	if err := a.AddComment(context.Background(), "post", &domain.Comment{}); err != nil {
+		t.Fatalf("comment on active post: %v", err)
+	}
+
+	expiry, _ := repo.GetPostExpiry(context.Background(), "post")
+	if want := clock.Now().Add(15 * time.Minute); !expiry.ExpiresAt.Equal(want) {
+		t.Fatalf("expected expiry %v, got %v", want, expiry.ExpiresAt)
+	}
+
+	clock.Advance(16 * time.Minute)
+	if err := a.AddComment(context.Background(), "post", &domain.Comment{}); !errors.Is(err, domain.ErrPostArchived) {
+		t.Fatalf("expected ErrPostArchived for expired thread, got %v", err)
+	}
+
+	a.Lock()
+	a.unschedulePost("post")
+	a.Unlock()
+}
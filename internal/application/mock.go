@@ -72,7 +72,7 @@ func (m *MockArchiveRepository) ArchivePostByID(ctx context.Context, id string)
 // Mock для CommentRepository
 type MockCommentRepository struct{}
 
-func (m *MockCommentRepository) AddComment(ctx context.Context, PostId string, comment *domain.Comment) error {
+func (m *MockCommentRepository) AddComment(ctx context.Context, PostId string, comment *domain.Comment, now, expiresAt time.Time) error {
 	if PostId == "valid-post" {
 		return nil // Успешное добавление комментария
 	}
@@ -100,8 +100,12 @@ func (m *MockUserRepository) GetUserByID(ctx context.Context, userID string) (*d
 	return nil, errors.New("user not found")
 }
 
-func (m *MockUserRepository) GetMaxCharacterID(ctx context.Context) (int, error) {
-	return 100, nil // Пример возврата максимального ID
+func (m *MockUserRepository) UpdateUsername(ctx context.Context, userID, username string) error {
+	return nil
+}
+
+func (m *MockUserRepository) NextCharacterSlot(ctx context.Context) (int64, error) {
+	return 1, nil
 }
 
 // Mock для SessionRepository
@@ -124,6 +128,14 @@ func (m *MockSessionRepository) SaveSession(ctx context.Context, session *domain
 	return nil
 }
 
+func (m *MockSessionRepository) TouchSession(ctx context.Context, sessionID string, now, expiresAt time.Time) error {
+	return nil
+}
+
+func (m *MockSessionRepository) DeleteExpiredSessions(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
 func (m *MockPostRepository) GetAllPosts(ctx context.Context) ([]domain.Post, error) {
 	// Возвращаем тестовые данные
 	return []domain.Post{
@@ -149,4 +161,40 @@ type MockDbPort struct {
 	MockCommentRepository
 	MockUserRepository
 	MockSessionRepository
+	MockModerationRepository
+}
+
+// Mock для ModerationRepository
+type MockModerationRepository struct{}
+
+func (m *MockModerationRepository) DeletePost(ctx context.Context, id string) error {
+	return nil
+}
+
+func (m *MockModerationRepository) DeleteComment(ctx context.Context, id string) error {
+	return nil
+}
+
+func (m *MockModerationRepository) RemovePostImages(ctx context.Context, id string) ([]string, error) {
+	return nil, nil
+}
+
+func (m *MockModerationRepository) RemoveCommentImages(ctx context.Context, id string) ([]string, error) {
+	return nil, nil
+}
+
+func (m *MockModerationRepository) SetPostLocked(ctx context.Context, id string, locked bool) error {
+	return nil
+}
+
+func (m *MockModerationRepository) SetPostPinned(ctx context.Context, id string, pinned bool, expiresAt time.Time) error {
+	return nil
+}
+
+func (m *MockModerationRepository) LogModeration(ctx context.Context, entry *domain.ModerationLogEntry) error {
+	return nil
+}
+
+func (m *MockModerationRepository) ListModerationLog(ctx context.Context, limit int) ([]domain.ModerationLogEntry, error) {
+	return nil, nil
 }
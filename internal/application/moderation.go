@@ -0,0 +1,155 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"1337b04rd/internal/domain"
+)
+
+// DeletePost удаляет тред вместе с комментариями. Изображения остаются в хранилище,
+// пока их не подберёт сборщик мусора; чтобы убрать их сразу, есть RemovePostImages.
+func (app *App) DeletePost(ctx context.Context, moderator, postID string) error {
+	post, err := app.repo.GetPostByID(ctx, postID)
+	if err != nil {
+		return fmt.Errorf("get post %s: %w", postID, err)
+	}
+	if err := app.repo.DeletePost(ctx, postID); err != nil {
+		return fmt.Errorf("delete post %s: %w", postID, err)
+	}
+
+	app.Lock()
+	app.unschedulePost(postID)
+	app.Unlock()
+
+	return app.logModeration(ctx, moderator, domain.ModDeletePost, domain.ModTargetPost, postID, post.Title)
+}
+
+// DeleteComment удаляет комментарий вместе с ответами на него
+func (app *App) DeleteComment(ctx context.Context, moderator, commentID string) error {
+	location, err := app.repo.GetCommentLocation(ctx, commentID)
+	if err != nil {
+		return fmt.Errorf("get comment %s: %w", commentID, err)
+	}
+	if err := app.repo.DeleteComment(ctx, commentID); err != nil {
+		return fmt.Errorf("delete comment %s: %w", commentID, err)
+	}
+	return app.logModeration(ctx, moderator, domain.ModDeleteComment, domain.ModTargetComment, commentID, "post "+location.PostID)
+}
+
+// RemovePostImages убирает из поста изображение и вложения и сразу удаляет их объекты.
+// Одинаковые изображения хранятся в одном объекте, поэтому хранилище в том же действии
+// убирает ссылки на них из всех постов и комментариев: модератор убирает само изображение,
+// где бы оно ни встречалось, и битых ссылок не остаётся. Повторная загрузка тех же байтов
+// создаст объект заново, но появится только в новом посте.
+func (app *App) RemovePostImages(ctx context.Context, moderator, postID string) error {
+	urls, err := app.repo.RemovePostImages(ctx, postID)
+	if err != nil {
+		return fmt.Errorf("remove images of post %s: %w", postID, err)
+	}
+	err = app.deleteImageObjects(ctx, urls)
+	if logErr := app.logModeration(ctx, moderator, domain.ModRemovePostImages, domain.ModTargetPost, postID, fmt.Sprintf("%d image URLs", len(urls))); logErr != nil {
+		return errors.Join(err, logErr)
+	}
+	return err
+}
+
+// RemoveCommentImages удаляет вложения комментария так же, как RemovePostImages
+func (app *App) RemoveCommentImages(ctx context.Context, moderator, commentID string) error {
+	urls, err := app.repo.RemoveCommentImages(ctx, commentID)
+	if err != nil {
+		return fmt.Errorf("remove images of comment %s: %w", commentID, err)
+	}
+	err = app.deleteImageObjects(ctx, urls)
+	if logErr := app.logModeration(ctx, moderator, domain.ModRemoveCommentImages, domain.ModTargetComment, commentID, fmt.Sprintf("%d image URLs", len(urls))); logErr != nil {
+		return errors.Join(err, logErr)
+	}
+	return err
+}
+
+// LockPost закрывает тред для новых комментариев или снова открывает его
+func (app *App) LockPost(ctx context.Context, moderator, postID string, locked bool) error {
+	if err := app.repo.SetPostLocked(ctx, postID, locked); err != nil {
+		return fmt.Errorf("lock post %s: %w", postID, err)
+	}
+	action := domain.ModLockPost
+	if !locked {
+		action = domain.ModUnlockPost
+	}
+	return app.logModeration(ctx, moderator, action, domain.ModTargetPost, postID, "")
+}
+
+// PinPost закрепляет активный тред вверху каталога. Закреплённый тред не уходит в архив;
+// после открепления он живёт ещё как минимум TTL.
+func (app *App) PinPost(ctx context.Context, moderator, postID string, pinned bool) error {
+	expiresAt := app.now().Add(app.lifetime.TTL)
+	if err := app.repo.SetPostPinned(ctx, postID, pinned, expiresAt); err != nil {
+		return fmt.Errorf("pin post %s: %w", postID, err)
+	}
+
+	action := domain.ModPinPost
+	if pinned {
+		app.Lock()
+		app.unschedulePost(postID)
+		app.Unlock()
+	} else {
+		action = domain.ModUnpinPost
+		expiry, err := app.repo.GetPostExpiry(ctx, postID)
+		if err != nil {
+			return fmt.Errorf("get expiry of post %s: %w", postID, err)
+		}
+		app.Lock()
+		app.schedulePost(postID, expiry.ExpiresAt)
+		app.Unlock()
+	}
+
+	return app.logModeration(ctx, moderator, action, domain.ModTargetPost, postID, "")
+}
+
+func (app *App) ModerationLog(ctx context.Context, limit int) ([]domain.ModerationLogEntry, error) {
+	entries, err := app.repo.ListModerationLog(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list moderation log: %w", err)
+	}
+	return entries, nil
+}
+
+func (app *App) logModeration(ctx context.Context, moderator string, action domain.ModerationAction, targetType, targetID, details string) error {
+	entry := &domain.ModerationLogEntry{
+		Moderator:  moderator,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Details:    details,
+		CreatedAt:  app.now(),
+	}
+	if err := app.repo.LogModeration(ctx, entry); err != nil {
+		return fmt.Errorf("log moderation: %w", err)
+	}
+	return nil
+}
+
+// deleteImageObjects удаляет объекты по URL вместе со всеми превью настроенных размеров.
+// Ошибки не прерывают удаление остальных объектов.
+func (app *App) deleteImageObjects(ctx context.Context, urls []string) error {
+	names := make(map[string]struct{})
+	for _, url := range urls {
+		name, ok := domain.ImageObjectName(url)
+		if !ok {
+			continue
+		}
+		names[name] = struct{}{}
+		for _, size := range app.images.ThumbnailSizes {
+			names[thumbnailName(name, size)] = struct{}{}
+		}
+	}
+
+	var errs []error
+	for name := range names {
+		if err := app.imageStorage.DeleteImage(ctx, name); err != nil && !errors.Is(err, domain.ErrNotFound) {
+			errs = append(errs, fmt.Errorf("delete image %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
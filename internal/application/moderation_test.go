@@ -0,0 +1,207 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"1337b04rd/internal/domain"
+)
+
+// moderationRepo дополняет threadRepo действиями модератора и журналом
+type moderationRepo struct {
+	*threadRepo
+	imageURLs []string
+	log       []domain.ModerationLogEntry
+}
+
+func (r *moderationRepo) SetPostLocked(ctx context.Context, id string, locked bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.posts[id]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	p.Locked = locked
+	return nil
+}
+
+func (r *moderationRepo) SetPostPinned(ctx context.Context, id string, pinned bool, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.posts[id]
+	if !ok || p.Archived {
+		return domain.ErrNotFound
+	}
+	p.Pinned = pinned
+	if !pinned && expiresAt.After(p.ExpiresAt) {
+		p.ExpiresAt = expiresAt
+	}
+	return nil
+}
+
+func (r *moderationRepo) RemovePostImages(ctx context.Context, id string) ([]string, error) {
+	if _, ok := r.posts[id]; !ok {
+		return nil, domain.ErrNotFound
+	}
+	urls := r.imageURLs
+	r.imageURLs = nil
+	return urls, nil
+}
+
+func (r *moderationRepo) LogModeration(ctx context.Context, entry *domain.ModerationLogEntry) error {
+	entry.ID = int64(len(r.log) + 1)
+	r.log = append(r.log, *entry)
+	return nil
+}
+
+func (r *moderationRepo) lastAction() domain.ModerationAction {
+	if len(r.log) == 0 {
+		return ""
+	}
+	return r.log[len(r.log)-1].Action
+}
+
+func TestLockPost(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	policy := DefaultLifetimePolicy()
+	policy.Clock = clock
+
+	repo := &moderationRepo{threadRepo: newThreadRepo(domain.PostExpiry{PostID: "post", ExpiresAt: clock.Now().Add(time.Hour)})}
+	a := NewApp(repo, nil, nil, userService{}, policy, DefaultImagePolicy(), time.Hour)
+	ctx := context.Background()
+
+	if err := a.LockPost(ctx, "mod", "post", true); err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	if err := a.AddComment(ctx, "post", &domain.Comment{}); !errors.Is(err, domain.ErrPostLocked) {
+		t.Fatalf("comment on locked post: %v, want ErrPostLocked", err)
+	}
+	if got := repo.lastAction(); got != domain.ModLockPost {
+		t.Errorf("logged %q, want %q", got, domain.ModLockPost)
+	}
+
+	if err := a.LockPost(ctx, "mod", "post", false); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	if err := a.AddComment(ctx, "post", &domain.Comment{}); err != nil {
+		t.Fatalf("comment on unlocked post: %v", err)
+	}
+	if got := repo.log[len(repo.log)-1]; got.Action != domain.ModUnlockPost || got.Moderator != "mod" || got.TargetID != "post" {
+		t.Errorf("log entry = %+v", got)
+	}
+
+	if err := a.LockPost(ctx, "mod", "missing", true); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("lock of missing post: %v, want ErrNotFound", err)
+	}
+	if len(repo.log) != 2 {
+		t.Errorf("failed action was logged: %+v", repo.log)
+	}
+}
+
+func TestPinPost(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	policy := DefaultLifetimePolicy()
+	policy.Clock = clock
+
+	repo := &moderationRepo{threadRepo: newThreadRepo(domain.PostExpiry{PostID: "post", ExpiresAt: clock.Now().Add(time.Minute)})}
+	a := NewApp(repo, nil, nil, userService{}, policy, DefaultImagePolicy(), time.Hour)
+	ctx := context.Background()
+
+	a.Lock()
+	a.schedulePost("post", clock.Now().Add(time.Minute))
+	a.Unlock()
+
+	if err := a.PinPost(ctx, "mod", "post", true); err != nil {
+		t.Fatalf("pin: %v", err)
+	}
+	a.Lock()
+	_, scheduled := a.Timers()["post"]
+	a.Unlock()
+	if scheduled {
+		t.Error("pinned post still has an archive timer")
+	}
+
+	// Закреплённый тред переживает свой срок, а комментарии не заводят таймер
+	clock.Advance(30 * time.Second)
+	if err := a.AddComment(ctx, "post", &domain.Comment{}); err != nil {
+		t.Fatalf("comment on pinned post: %v", err)
+	}
+	clock.Advance(time.Hour)
+	if err := a.syncExpirySchedule(ctx); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if expiry, _ := repo.GetPostExpiry(ctx, "post"); expiry.Archived {
+		t.Fatal("pinned post was archived")
+	}
+	// Срок давно прошёл, но в закреплённый тред по-прежнему можно писать
+	if err := a.AddComment(ctx, "post", &domain.Comment{}); err != nil {
+		t.Fatalf("comment on pinned post after its deadline: %v", err)
+	}
+	a.Lock()
+	timers := len(a.Timers())
+	a.Unlock()
+	if timers != 0 {
+		t.Errorf("pinned post is scheduled: %d timers", timers)
+	}
+
+	if err := a.PinPost(ctx, "mod", "post", false); err != nil {
+		t.Fatalf("unpin: %v", err)
+	}
+	expiry, _ := repo.GetPostExpiry(ctx, "post")
+	if want := clock.Now().Add(policy.TTL); expiry.ExpiresAt.Before(want) {
+		t.Errorf("unpinned post expires at %v, want at least %v", expiry.ExpiresAt, want)
+	}
+	a.Lock()
+	deadline, scheduled := a.deadlines["post"]
+	a.Unlock()
+	if !scheduled || !deadline.Equal(expiry.ExpiresAt) {
+		t.Errorf("unpinned post deadline = %v (scheduled=%v), want %v", deadline, scheduled, expiry.ExpiresAt)
+	}
+
+	var actions []domain.ModerationAction
+	for _, e := range repo.log {
+		actions = append(actions, e.Action)
+	}
+	if len(actions) != 2 || actions[0] != domain.ModPinPost || actions[1] != domain.ModUnpinPost {
+		t.Errorf("logged actions = %v", actions)
+	}
+}
+
+func TestRemovePostImages(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	storage := newMemoryStorage(clock)
+	for _, name := range []string{"a.png", "a_200.jpg", "a_400.jpg", "b.gif", "b_200.jpg", "b_400.jpg", "other.png"} {
+		storage.objects[name] = []byte(name)
+	}
+
+	images := DefaultImagePolicy()
+	images.ThumbnailSizes = []int{200, 400}
+
+	repo := &moderationRepo{
+		threadRepo: newThreadRepo(domain.PostExpiry{PostID: "post"}),
+		imageURLs: []string{
+			domain.ImageURL("a.png"), domain.ImageURL("a_200.jpg"),
+			domain.ImageURL("b.gif"), domain.ImageURL("b_200.jpg"),
+			"https://example.com/external.png",
+		},
+	}
+	a := NewApp(repo, nil, storage, userService{}, DefaultLifetimePolicy(), images, time.Hour)
+
+	if err := a.RemovePostImages(context.Background(), "mod", "post"); err != nil {
+		t.Fatalf("remove images: %v", err)
+	}
+
+	if len(storage.objects) != 1 {
+		t.Errorf("objects left: %v, want only other.png", storage.objects)
+	}
+	if _, ok := storage.objects["other.png"]; !ok {
+		t.Error("unrelated object was deleted")
+	}
+	if len(repo.log) != 1 || repo.log[0].Action != domain.ModRemovePostImages || repo.log[0].Details != "5 image URLs" {
+		t.Errorf("log = %+v", repo.log)
+	}
+}
@@ -2,24 +2,103 @@ package application
 
 import (
 	"errors"
+	"fmt"
 	"math/rand"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"1337b04rd/internal/domain"
 )
 
-type userService struct{}
+type userService struct {
+	names UsernamePolicy
+}
+
+func NewUser(names UsernamePolicy) *userService {
+	return &userService{names: names}
+}
+
+// UsernamePolicy — правила выбора имени пользователем
+type UsernamePolicy struct {
+	// MinLength и MaxLength ограничивают длину имени в символах
+	MinLength int
+	MaxLength int
+	// Blocklist — запрещённые слова; имя отклоняется, если содержит любое из них без учёта регистра
+	Blocklist []string
+	// Filter — дополнительная проверка, например внешний фильтр нецензурных слов.
+	// Ошибка фильтра показывается пользователю.
+	Filter func(name string) error
+}
+
+func DefaultUsernamePolicy() UsernamePolicy {
+	return UsernamePolicy{
+		MinLength: 2,
+		MaxLength: 32,
+	}
+}
 
-func NewUser() *userService {
-	return &userService{}
+func (p UsernamePolicy) Validate() error {
+	if p.MinLength <= 0 {
+		return errors.New("username min length must be positive")
+	}
+	if p.MaxLength < p.MinLength {
+		return errors.New("username max length must not be less than min length")
+	}
+	return nil
 }
 
+// usernamePunctuation — допустимые в имени символы помимо букв, цифр и пробела
+const usernamePunctuation = "-_.'"
+
+// NormalizeUsername убирает лишние пробелы и проверяет имя по правилам.
+// Ошибки оборачивают domain.ErrInvalidUsername и пригодны для показа пользователю.
+func (user *userService) NormalizeUsername(name string) (string, error) {
+	name = strings.Join(strings.Fields(name), " ")
+
+	length := utf8.RuneCountInString(name)
+	if length < user.names.MinLength || length > user.names.MaxLength {
+		return "", fmt.Errorf("%w: name must be %d to %d characters long", domain.ErrInvalidUsername, user.names.MinLength, user.names.MaxLength)
+	}
+
+	hasAlnum := false
+	for _, r := range name {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r):
+			hasAlnum = true
+		case r == ' ', strings.ContainsRune(usernamePunctuation, r):
+		default:
+			return "", fmt.Errorf("%w: name may contain only letters, digits, spaces and %s", domain.ErrInvalidUsername, usernamePunctuation)
+		}
+	}
+	if !hasAlnum {
+		return "", fmt.Errorf("%w: name must contain a letter or a digit", domain.ErrInvalidUsername)
+	}
+
+	lower := strings.ToLower(name)
+	for _, word := range user.names.Blocklist {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" && strings.Contains(lower, word) {
+			return "", fmt.Errorf("%w: name is not allowed", domain.ErrInvalidUsername)
+		}
+	}
+	if user.names.Filter != nil {
+		if err := user.names.Filter(name); err != nil {
+			return "", fmt.Errorf("%w: %v", domain.ErrInvalidUsername, err)
+		}
+	}
+	return name, nil
+}
+
+// GenUserID выбирает персонажа для нового пользователя. Первые maxID пользователей
+// получают персонажей по порядку (1..maxID), следующие — случайных из того же диапазона.
+// userCount — сколько персонажей было выдано до этого пользователя.
 func (user *userService) GenUserID(userCount, maxID int) (int, error) {
-	if maxID == 0 {
+	if maxID <= 0 {
 		return 0, errors.New("maxID is zero")
 	}
 
-	id := userCount + 1
-	if userCount > maxID {
-		id = rand.Intn(maxID)
+	if userCount < maxID {
+		return userCount + 1, nil
 	}
-
-	return id, nil
+	return rand.Intn(maxID) + 1, nil
 }
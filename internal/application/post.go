@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"time"
 
 	"1337b04rd/internal/domain"
 
@@ -12,26 +11,18 @@ import (
 )
 
 func (app *App) CreatePost(ctx context.Context, post *domain.Post) error {
-	app.Lock()
-	defer app.Unlock()
+	if post.CreatedAt.IsZero() {
+		post.CreatedAt = app.now()
+	}
+	post.ExpiresAt = app.lifetime.InitialExpiry(post.CreatedAt)
 
 	if err := app.repo.CreatePost(ctx, post); err != nil {
 		return err
 	}
 
-	if timer, ok := app.timers[post.ID]; ok {
-		timer.Stop()
-	}
-	app.timers[post.ID] = time.AfterFunc(10*time.Minute, func() {
-		app.Lock()
-		defer app.Unlock()
-
-		if timer, ok := app.timers[post.ID]; ok {
-			delete(app.timers, post.ID)
-			go app.archivePost(context.Background(), post.ID)
-			timer.Stop()
-		}
-	})
+	app.Lock()
+	defer app.Unlock()
+	app.schedulePost(post.ID, post.ExpiresAt)
 
 	return nil
 }
@@ -103,13 +94,3 @@ func (app *App) GetArchivedPostByID(ctx context.Context, id string) (*domain.Pos
 
 	return post, nil
 }
-
-func (app *App) archivePost(ctx context.Context, id string) (*domain.Post, error) {
-	post, err := app.repo.ArchivePostByID(ctx, id)
-	if err != nil {
-		fmt.Printf("Failed to archive post %s: %v\n", id, err)
-		return nil, err
-	}
-	fmt.Printf("Successfully archived post %s\n", id)
-	return post, nil
-}
@@ -2,19 +2,22 @@ package application
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"1337b04rd/internal/domain"
 	"1337b04rd/pkg"
 )
 
+const sessionCleanupTimeout = time.Minute
+
 func (app *App) GetSessionByID(ctx context.Context, sessionID string) (*domain.Session, error) {
 	session, err := app.repo.GetSession(ctx, sessionID)
 	if err != nil {
 		return nil, err
 	}
 
-	session.IsActive = session.ExpiresAt.After(time.Now())
+	session.IsActive = session.ExpiresAt.After(app.now())
 	return session, nil
 }
 
@@ -31,11 +34,12 @@ func (app *App) CreateSession(ctx context.Context) (*domain.Session, error) {
 		return nil, err
 	}
 
+	now := app.now()
 	session := &domain.Session{
 		ID:        sessionID,
 		UserID:    user.ID,
-		CreatedAt: time.Now(),
-		ExpiresAt: time.Now().Add(10 * time.Minute),
+		CreatedAt: now,
+		ExpiresAt: now.Add(app.sessionTTL),
 		IsActive:  true,
 	}
 
@@ -46,3 +50,55 @@ func (app *App) CreateSession(ctx context.Context) (*domain.Session, error) {
 
 	return session, nil
 }
+
+// RenewSession реализует скользящий срок жизни: пока пользователь активен, сессия
+// и вместе с ней его имя и аватар не истекают. Чтобы не писать в БД на каждый запрос,
+// срок продлевается, только если с прошлого продления прошло больше десятой части TTL.
+func (app *App) RenewSession(ctx context.Context, session *domain.Session) (bool, error) {
+	now := app.now()
+	if session.ExpiresAt.Sub(now) > app.sessionTTL-app.sessionTTL/10 {
+		return false, nil
+	}
+
+	expiresAt := now.Add(app.sessionTTL)
+	if err := app.repo.TouchSession(ctx, session.ID, now, expiresAt); err != nil {
+		return false, fmt.Errorf("touch session: %w", err)
+	}
+	session.ExpiresAt = expiresAt
+	return true, nil
+}
+
+// StartSessionCleanup каждые interval удаляет истёкшие сессии. Останавливается вместе с ctx.
+func (app *App) StartSessionCleanup(ctx context.Context, interval time.Duration) {
+	app.background.Add(1)
+	go func() {
+		defer app.background.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				cleanupCtx, cancel := context.WithTimeout(ctx, sessionCleanupTimeout)
+				deleted, err := app.DeleteExpiredSessions(cleanupCtx)
+				cancel()
+				if err != nil {
+					fmt.Printf("Failed to delete expired sessions: %v\n", err)
+					continue
+				}
+				if deleted > 0 {
+					fmt.Printf("Deleted %d expired sessions\n", deleted)
+				}
+			}
+		}
+	}()
+}
+
+// DeleteExpiredSessions удаляет сессии, истёкшие к текущему моменту. Пользователи
+// остаются: на них ссылаются их посты и комментарии.
+func (app *App) DeleteExpiredSessions(ctx context.Context) (int64, error) {
+	return app.repo.DeleteExpiredSessions(ctx, app.now())
+}
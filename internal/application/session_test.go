@@ -0,0 +1,102 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"1337b04rd/internal/domain"
+	"1337b04rd/internal/ports/right"
+)
+
+// sessionRepo хранит сессии в памяти и считает обращения к БД
+type sessionRepo struct {
+	right.DbPort
+	sessions map[string]domain.Session
+	touches  int
+}
+
+func (r *sessionRepo) GetSession(ctx context.Context, sessionID string) (*domain.Session, error) {
+	session, ok := r.sessions[sessionID]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &session, nil
+}
+
+func (r *sessionRepo) TouchSession(ctx context.Context, sessionID string, now, expiresAt time.Time) error {
+	r.touches++
+	session, ok := r.sessions[sessionID]
+	if !ok || !session.ExpiresAt.After(now) {
+		return domain.ErrNotFound
+	}
+	if expiresAt.After(session.ExpiresAt) {
+		session.ExpiresAt = expiresAt
+	}
+	r.sessions[sessionID] = session
+	return nil
+}
+
+func (r *sessionRepo) DeleteExpiredSessions(ctx context.Context, before time.Time) (int64, error) {
+	var deleted int64
+	for id, session := range r.sessions {
+		if !session.ExpiresAt.After(before) {
+			delete(r.sessions, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func TestRenewSession_SlidesExpiry(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	policy := DefaultLifetimePolicy()
+	policy.Clock = clock
+	ttl := 10 * time.Hour
+
+	repo := &sessionRepo{sessions: map[string]domain.Session{
+		"s1": {ID: "s1", UserID: "u1", ExpiresAt: clock.Now().Add(ttl)},
+	}}
+	a := NewApp(repo, nil, nil, userService{}, policy, DefaultImagePolicy(), ttl)
+	ctx := context.Background()
+
+	// Сразу после продления срок не трогается
+	clock.Advance(30 * time.Minute)
+	session, _ := a.GetSessionByID(ctx, "s1")
+	if renewed, err := a.RenewSession(ctx, session); err != nil || renewed {
+		t.Fatalf("renewed = %v, %v; want no renewal yet", renewed, err)
+	}
+	if repo.touches != 0 {
+		t.Fatalf("touches = %d, want 0", repo.touches)
+	}
+
+	// Активный пользователь остаётся тем же, хотя исходный срок уже прошёл бы
+	for i := 0; i < 5; i++ {
+		clock.Advance(3 * time.Hour)
+		session, err := a.GetSessionByID(ctx, "s1")
+		if err != nil || !session.IsActive {
+			t.Fatalf("step %d: session = %+v, %v; want active", i, session, err)
+		}
+		renewed, err := a.RenewSession(ctx, session)
+		if err != nil || !renewed {
+			t.Fatalf("step %d: renewed = %v, %v", i, renewed, err)
+		}
+		if want := clock.Now().Add(ttl); !session.ExpiresAt.Equal(want) || !repo.sessions["s1"].ExpiresAt.Equal(want) {
+			t.Fatalf("step %d: expires at %v, stored %v, want %v", i, session.ExpiresAt, repo.sessions["s1"].ExpiresAt, want)
+		}
+	}
+
+	// Без визитов сессия истекает и удаляется фоновой очисткой
+	clock.Advance(ttl)
+	session, _ = a.GetSessionByID(ctx, "s1")
+	if session.IsActive {
+		t.Fatalf("session is still active after TTL without activity")
+	}
+	if _, err := a.RenewSession(ctx, session); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("renew expired session: err = %v, want ErrNotFound", err)
+	}
+	if deleted, err := a.DeleteExpiredSessions(ctx); err != nil || deleted != 1 {
+		t.Fatalf("deleted = %d, %v; want 1", deleted, err)
+	}
+}
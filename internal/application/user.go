@@ -2,18 +2,33 @@ package application
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"1337b04rd/internal/domain"
 	"1337b04rd/pkg"
 )
 
-func (app *App) getUser(ctx context.Context, userID string) error {
-	return nil
+func (app *App) GetUser(ctx context.Context, userID string) (*domain.User, error) {
+	return app.repo.GetUserByID(ctx, userID)
+}
+
+// SetUsername меняет имя пользователя, аватар остаётся прежним.
+// Новое имя появится только в новых постах и комментариях.
+func (app *App) SetUsername(ctx context.Context, userID, name string) (*domain.User, error) {
+	name, err := app.userService.NormalizeUsername(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := app.repo.UpdateUsername(ctx, userID, name); err != nil {
+		return nil, fmt.Errorf("update username: %w", err)
+	}
+	return app.repo.GetUserByID(ctx, userID)
 }
 
 func (app *App) createUser(ctx context.Context) (*domain.User, error) {
-	domain_user, err := app.avatarProvider.GetRandomAvatar()
+	character, err := app.assignCharacter(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -24,8 +39,8 @@ func (app *App) createUser(ctx context.Context) (*domain.User, error) {
 	}
 	newUser := &domain.User{
 		ID:        userID,
-		Username:  domain_user.Username,
-		ImageURL:  domain_user.ImageURL,
+		Username:  character.Name,
+		ImageURL:  character.ImageURL,
 		CreatedAt: time.Now(),
 	}
 
@@ -35,3 +50,24 @@ func (app *App) createUser(ctx context.Context) (*domain.User, error) {
 
 	return newUser, nil
 }
+
+// assignCharacter выдаёт персонажей по порядку, пока они не закончатся, а затем случайных.
+// Номер берётся из последовательности в БД, поэтому параллельные запросы и реплики
+// не получают одного и того же персонажа.
+func (app *App) assignCharacter(ctx context.Context) (*domain.Character, error) {
+	slot, err := app.repo.NextCharacterSlot(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("next character slot: %w", err)
+	}
+	count, err := app.avatarProvider.CharacterCount(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("count characters: %w", err)
+	}
+
+	// Номера выдаются с 1, а GenUserID ждёт число уже выданных персонажей
+	id, err := app.userService.GenUserID(int(slot-1), count)
+	if err != nil {
+		return nil, err
+	}
+	return app.avatarProvider.GetRandomAvatarByID(ctx, id)
+}
@@ -0,0 +1,198 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"1337b04rd/internal/domain"
+	"1337b04rd/internal/ports/right"
+)
+
+// fakeAvatars — провайдер с count персонажами, имена которых совпадают с их номерами
+type fakeAvatars struct {
+	count int
+}
+
+func (p fakeAvatars) CharacterCount(ctx context.Context) (int, error) {
+	return p.count, nil
+}
+
+func (p fakeAvatars) GetRandomAvatar(ctx context.Context) (*domain.Character, error) {
+	panic("characters must be assigned by slot")
+}
+
+func (p fakeAvatars) GetRandomAvatarByID(ctx context.Context, id int) (*domain.Character, error) {
+	if id < 1 || id > p.count {
+		return nil, fmt.Errorf("character %d: %w", id, domain.ErrNotFound)
+	}
+	return &domain.Character{ID: id, Name: fmt.Sprintf("character-%d", id), ImageURL: "/images/avatar.png"}, nil
+}
+
+// slotRepo имитирует character_slot_seq
+type slotRepo struct {
+	right.DbPort
+	slot  atomic.Int64
+	mu    sync.Mutex
+	users []*domain.User
+}
+
+func (r *slotRepo) NextCharacterSlot(ctx context.Context) (int64, error) {
+	return r.slot.Add(1), nil
+}
+
+func (r *slotRepo) CreateUser(ctx context.Context, user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users = append(r.users, user)
+	return nil
+}
+
+func TestGenUserID(t *testing.T) {
+	s := NewUser(DefaultUsernamePolicy())
+
+	for userCount := 0; userCount < 3; userCount++ {
+		id, err := s.GenUserID(userCount, 3)
+		if err != nil || id != userCount+1 {
+			t.Errorf("GenUserID(%d, 3) = %d, %v; want %d", userCount, id, err, userCount+1)
+		}
+	}
+	for i := 0; i < 100; i++ {
+		id, err := s.GenUserID(3+i, 3)
+		if err != nil || id < 1 || id > 3 {
+			t.Fatalf("GenUserID(%d, 3) = %d, %v; want 1..3", 3+i, id, err)
+		}
+	}
+	if _, err := s.GenUserID(0, 0); err == nil {
+		t.Errorf("GenUserID with empty pool succeeded")
+	}
+}
+
+func TestCreateUser_AssignsUniqueCharacters(t *testing.T) {
+	const pool, visitors = 20, 30
+
+	repo := &slotRepo{}
+	a := NewApp(repo, fakeAvatars{count: pool}, nil, userService{}, DefaultLifetimePolicy(), DefaultImagePolicy(), time.Hour)
+
+	var wg sync.WaitGroup
+	for i := 0; i < visitors; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := a.createUser(context.Background()); err != nil {
+				t.Errorf("create user: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(repo.users) != visitors {
+		t.Fatalf("created %d users, want %d", len(repo.users), visitors)
+	}
+	seen := make(map[string]int)
+	for _, u := range repo.users {
+		seen[u.Username]++
+	}
+	// Все персонажи пула выданы, повторы начинаются только после его исчерпания
+	for id := 1; id <= pool; id++ {
+		if seen[fmt.Sprintf("character-%d", id)] == 0 {
+			t.Errorf("character %d was never assigned", id)
+		}
+	}
+	if len(seen) != pool {
+		t.Errorf("assigned %d distinct characters, want %d", len(seen), pool)
+	}
+}
+
+func TestNormalizeUsername(t *testing.T) {
+	policy := DefaultUsernamePolicy()
+	policy.Blocklist = []string{"admin"}
+	policy.Filter = func(name string) error {
+		if strings.Contains(name, "heck") {
+			return errors.New("name is rude")
+		}
+		return nil
+	}
+	s := NewUser(policy)
+
+	tests := []struct {
+		name string
+		want string
+		ok   bool
+	}{
+		{name: "  Pickle   Rick ", want: "Pickle Rick", ok: true},
+		{name: "Mr. Poopybutthole", want: "Mr. Poopybutthole", ok: true},
+		{name: "Жора_42", want: "Жора_42", ok: true},
+		{name: "x", ok: false},
+		{name: strings.Repeat("a", 33), ok: false},
+		{name: "<script>", ok: false},
+		{name: "---", ok: false},
+		{name: "Real ADMIN", ok: false},
+		{name: "what the heck", ok: false},
+	}
+	for _, tt := range tests {
+		got, err := s.NormalizeUsername(tt.name)
+		if tt.ok && (err != nil || got != tt.want) {
+			t.Errorf("NormalizeUsername(%q) = %q, %v; want %q", tt.name, got, err, tt.want)
+		}
+		if !tt.ok && !errors.Is(err, domain.ErrInvalidUsername) {
+			t.Errorf("NormalizeUsername(%q) = %q, %v; want ErrInvalidUsername", tt.name, got, err)
+		}
+	}
+}
+
+// usernameRepo хранит пользователей в памяти
+type usernameRepo struct {
+	right.DbPort
+	users map[string]*domain.User
+}
+
+func (r *usernameRepo) GetUserByID(ctx context.Context, userID string) (*domain.User, error) {
+	user, ok := r.users[userID]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *usernameRepo) UpdateUsername(ctx context.Context, userID, username string) error {
+	user, ok := r.users[userID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	user.Username = username
+	return nil
+}
+
+func TestSetUsername(t *testing.T) {
+	repo := &usernameRepo{users: map[string]*domain.User{
+		"u1": {ID: "u1", Username: "Rick Sanchez", ImageURL: "/images/avatar_1.jpg"},
+	}}
+	a := NewApp(repo, nil, nil, *NewUser(DefaultUsernamePolicy()), DefaultLifetimePolicy(), DefaultImagePolicy(), time.Hour)
+	ctx := context.Background()
+
+	user, err := a.SetUsername(ctx, "u1", " Tiny  Rick ")
+	if err != nil {
+		t.Fatalf("set username: %v", err)
+	}
+	if user.Username != "Tiny Rick" || user.ImageURL != "/images/avatar_1.jpg" {
+		t.Errorf("user = %+v, want new name and the same avatar", user)
+	}
+
+	if _, err := a.SetUsername(ctx, "u1", "?"); !errors.Is(err, domain.ErrInvalidUsername) {
+		t.Errorf("err = %v, want ErrInvalidUsername", err)
+	}
+	if repo.users["u1"].Username != "Tiny Rick" {
+		t.Errorf("invalid name was saved: %+v", repo.users["u1"])
+	}
+
+	if _, err := a.SetUsername(ctx, "missing", "Morty"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound", err)
+	}
+}
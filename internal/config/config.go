@@ -0,0 +1,647 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config — настройки всех адаптеров приложения.
+// Значения берутся из переменных окружения; если задан CONFIG_FILE,
+// сначала читается он (формат KEY=VALUE), а окружение имеет приоритет.
+type Config struct {
+	HTTP       HTTPConfig
+	DB         DBConfig
+	Storage    StorageConfig
+	Image      ImageConfig
+	Minio      MinioConfig
+	Avatar     AvatarConfig
+	Session    SessionConfig
+	Username   UsernameConfig
+	RateLimit  RateLimitConfig
+	Moderation ModerationConfig
+	Thread     ThreadConfig
+	Log        LogConfig
+	Migrations MigrationsConfig
+}
+
+type HTTPConfig struct {
+	Addr           string
+	ReadTimeout    time.Duration
+	WriteTimeout   time.Duration
+	IdleTimeout    time.Duration
+	RequestTimeout time.Duration
+	MaxUploadSize  int64
+	// MaxAttachments и MaxAttachmentsSize ограничивают число и общий размер
+	// изображений в одном посте или комментарии
+	MaxAttachments     int
+	MaxAttachmentsSize int64
+	// ShutdownTimeout — сколько ждать завершения активных запросов при остановке
+	ShutdownTimeout time.Duration
+	// ShutdownGrace — сколько после сигнала остановки readiness отвечает 503 до закрытия
+	// слушателей, чтобы балансировщик успел убрать реплику
+	ShutdownGrace time.Duration
+	// CookieSecure — выдавать cookie сессии только по HTTPS (с префиксом __Host-)
+	CookieSecure bool
+	// CSRFSecret — ключ CSRF-токенов; если пуст, генерируется при запуске
+	CSRFSecret string
+}
+
+type DBConfig struct {
+	Host           string
+	Port           int
+	User           string
+	Password       string
+	Name           string
+	SSLMode        string
+	ConnectTimeout time.Duration
+	MaxOpenConns   int
+}
+
+// DSN собирает строку подключения для драйвера pgx
+func (c DBConfig) DSN() string {
+	u := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(c.User, c.Password),
+		Host:   fmt.Sprintf("%s:%d", c.Host, c.Port),
+		Path:   c.Name,
+	}
+	q := url.Values{}
+	q.Set("sslmode", c.SSLMode)
+	q.Set("connect_timeout", strconv.Itoa(int(c.ConnectTimeout.Seconds())))
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+const (
+	StorageMinio = "minio"
+	StorageFS    = "fs"
+)
+
+type StorageConfig struct {
+	// Backend — где хранить изображения: "minio" или "fs"
+	Backend string
+	// Dir — корневой каталог для Backend = "fs"
+	Dir string
+}
+
+// ImageConfig ограничивает размеры загружаемых изображений в пикселях
+// и задаёт размеры превью
+type ImageConfig struct {
+	MaxWidth  int
+	MaxHeight int
+	MaxPixels int64
+	MaxFrames int
+	// ThumbnailSizes — первый размер используется в каталоге и архиве
+	ThumbnailSizes []int
+	// GCInterval — как часто удалять объекты без ссылок (0 — не удалять)
+	GCInterval time.Duration
+	// GCGrace — возраст, раньше которого объект без ссылок не удаляется
+	GCGrace time.Duration
+}
+
+type MinioConfig struct {
+	Endpoint       string
+	AccessKey      string
+	SecretKey      string
+	Bucket         string
+	UseSSL         bool
+	ConnectRetries int
+	RetryDelay     time.Duration
+}
+
+const (
+	AvatarRickAndMorty = "rickandmorty"
+	AvatarIdenticon    = "identicon"
+)
+
+type AvatarConfig struct {
+	// Provider — откуда брать персонажей: "rickandmorty" или "identicon"
+	Provider string
+	// Fallback — генерировать identicon, если Rick and Morty API недоступен, а кэш пуст
+	Fallback bool
+}
+
+type SessionConfig struct {
+	// TTL — срок жизни сессии и её cookie; продлевается при каждом визите
+	TTL time.Duration
+	// CleanupInterval — как часто удалять истёкшие сессии (0 — не удалять)
+	CleanupInterval time.Duration
+}
+
+// UsernameConfig — ограничения на имена, которые выбирают пользователи
+type UsernameConfig struct {
+	MinLength int
+	MaxLength int
+	// Blocklist — запрещённые в имени слова
+	Blocklist []string
+}
+
+const (
+	RateLimitMemory   = "memory"
+	RateLimitPostgres = "postgres"
+)
+
+// Rate — не больше Burst действий подряд, полностью восстанавливается за Interval.
+// Нулевой Rate отключает лимит.
+type Rate struct {
+	Burst    int
+	Interval time.Duration
+}
+
+type RateLimitConfig struct {
+	// Store — где хранить состояние лимитов: "memory" или "postgres" (общее для реплик)
+	Store    string
+	Posts    Rate
+	Comments Rate
+	// Images считается по числу загружаемых изображений
+	Images Rate
+	// Logins — попытки входа модератора с одного адреса
+	Logins Rate
+	// TrustedProxies — прокси, которым можно верить в X-Forwarded-For
+	TrustedProxies []*net.IPNet
+	// PruneInterval — как часто забывать неактивных клиентов
+	PruneInterval time.Duration
+}
+
+// ModerationConfig — учётная запись модератора. Пустой Password отключает модерацию.
+type ModerationConfig struct {
+	Username string
+	Password string
+	// SessionTTL — сколько действует вход модератора
+	SessionTTL time.Duration
+}
+
+// Enabled сообщает, настроен ли вход модератора
+func (c ModerationConfig) Enabled() bool {
+	return c.Password != ""
+}
+
+type ThreadConfig struct {
+	TTL           time.Duration
+	CommentTTL    time.Duration
+	MaxLifetime   time.Duration
+	BumpLimit     int
+	SweepInterval time.Duration
+	// ArchiveRetention — сколько хранить архивные треды (0 — бессрочно)
+	ArchiveRetention time.Duration
+}
+
+type LogConfig struct {
+	Dir string
+}
+
+type MigrationsConfig struct {
+	Dir string
+	// OnStart — применять миграции при запуске сервера
+	OnStart bool
+}
+
+// Load читает конфигурацию и проверяет её. Все ошибки возвращаются разом.
+func Load() (*Config, error) {
+	l := &loader{file: map[string]string{}}
+
+	if path, ok := os.LookupEnv("CONFIG_FILE"); ok && path != "" {
+		file, err := readEnvFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read config file: %w", err)
+		}
+		l.file = file
+	}
+
+	cfg := &Config{
+		HTTP: HTTPConfig{
+			Addr:               ":" + l.str("PORT", "8080"),
+			ReadTimeout:        l.duration("HTTP_READ_TIMEOUT", 15*time.Second),
+			WriteTimeout:       l.duration("HTTP_WRITE_TIMEOUT", 60*time.Second),
+			IdleTimeout:        l.duration("HTTP_IDLE_TIMEOUT", 120*time.Second),
+			RequestTimeout:     l.duration("REQUEST_TIMEOUT", 5*time.Second),
+			MaxUploadSize:      l.size("MAX_UPLOAD_SIZE", 10<<20),
+			MaxAttachments:     l.int("MAX_ATTACHMENTS", 4),
+			MaxAttachmentsSize: l.size("MAX_ATTACHMENTS_SIZE", 8<<20),
+			ShutdownTimeout:    l.duration("HTTP_SHUTDOWN_TIMEOUT", 30*time.Second),
+			ShutdownGrace:      l.duration("HTTP_SHUTDOWN_GRACE", 5*time.Second),
+			CookieSecure:       l.bool("COOKIE_SECURE", false),
+			CSRFSecret:         l.str("CSRF_SECRET", ""),
+		},
+		DB: DBConfig{
+			Host:           l.str("DB_HOST", "db"),
+			Port:           l.int("DB_PORT", 5432),
+			User:           l.str("DB_USER", "postgres"),
+			Password:       l.str("DB_PASSWORD", "postgres"),
+			Name:           l.str("DB_NAME", "1337board"),
+			SSLMode:        l.str("DB_SSLMODE", "disable"),
+			ConnectTimeout: l.duration("DB_CONNECT_TIMEOUT", 5*time.Second),
+			MaxOpenConns:   l.int("DB_MAX_OPEN_CONNS", 10),
+		},
+		Storage: StorageConfig{
+			Backend: l.str("STORAGE_BACKEND", StorageMinio),
+			Dir:     l.str("STORAGE_DIR", "data/images"),
+		},
+		Image: ImageConfig{
+			MaxWidth:  l.int("IMAGE_MAX_WIDTH", 8192),
+			MaxHeight: l.int("IMAGE_MAX_HEIGHT", 8192),
+			MaxPixels: int64(l.int("IMAGE_MAX_PIXELS", 40_000_000)),
+			MaxFrames: l.int("IMAGE_MAX_FRAMES", 200),
+			// Превью в каталоге и крупное для ленты архива
+			ThumbnailSizes: l.ints("THUMBNAIL_SIZES", []int{200, 400}),
+			GCInterval:     l.duration("IMAGE_GC_INTERVAL", time.Hour),
+			GCGrace:        l.duration("IMAGE_GC_GRACE", time.Hour),
+		},
+		Minio: MinioConfig{
+			Endpoint:       l.str("MINIO_ENDPOINT", "minio:9000"),
+			AccessKey:      l.str("MINIO_ACCESS_KEY", "minioadmin"),
+			SecretKey:      l.str("MINIO_SECRET_KEY", "minioadmin"),
+			Bucket:         l.str("MINIO_BUCKET", "images"),
+			UseSSL:         l.bool("MINIO_USE_SSL", false),
+			ConnectRetries: l.int("MINIO_CONNECT_RETRIES", 10),
+			RetryDelay:     l.duration("MINIO_RETRY_DELAY", 2*time.Second),
+		},
+		Avatar: AvatarConfig{
+			Provider: l.str("AVATAR_PROVIDER", AvatarRickAndMorty),
+			Fallback: l.bool("AVATAR_FALLBACK", true),
+		},
+		Session: SessionConfig{
+			TTL:             l.duration("SESSION_TTL", 7*24*time.Hour),
+			CleanupInterval: l.duration("SESSION_CLEANUP_INTERVAL", time.Hour),
+		},
+		Username: UsernameConfig{
+			MinLength: l.int("USERNAME_MIN_LENGTH", 2),
+			MaxLength: l.int("USERNAME_MAX_LENGTH", 32),
+			Blocklist: l.strs("USERNAME_BLOCKLIST", nil),
+		},
+		RateLimit: RateLimitConfig{
+			Store:          l.str("RATE_LIMIT_STORE", RateLimitMemory),
+			Posts:          l.rate("RATE_LIMIT_POSTS", Rate{Burst: 3, Interval: 10 * time.Minute}),
+			Comments:       l.rate("RATE_LIMIT_COMMENTS", Rate{Burst: 10, Interval: time.Minute}),
+			Images:         l.rate("RATE_LIMIT_IMAGES", Rate{Burst: 20, Interval: 10 * time.Minute}),
+			Logins:         l.rate("RATE_LIMIT_LOGINS", Rate{Burst: 5, Interval: 15 * time.Minute}),
+			TrustedProxies: l.networks("TRUSTED_PROXIES"),
+			PruneInterval:  l.duration("RATE_LIMIT_PRUNE_INTERVAL", 10*time.Minute),
+		},
+		Moderation: ModerationConfig{
+			Username:   l.str("MOD_USERNAME", "moderator"),
+			Password:   l.str("MOD_PASSWORD", ""),
+			SessionTTL: l.duration("MOD_SESSION_TTL", 12*time.Hour),
+		},
+		Thread: ThreadConfig{
+			TTL:              l.duration("THREAD_TTL", 10*time.Minute),
+			CommentTTL:       l.duration("THREAD_COMMENT_TTL", 15*time.Minute),
+			MaxLifetime:      l.duration("THREAD_MAX_LIFETIME", 0),
+			BumpLimit:        l.int("THREAD_BUMP_LIMIT", 0),
+			SweepInterval:    l.duration("THREAD_SWEEP_INTERVAL", time.Minute),
+			ArchiveRetention: l.duration("ARCHIVE_RETENTION", 0),
+		},
+		Log: LogConfig{
+			Dir: l.str("LOG_DIR", "/logs"),
+		},
+		Migrations: MigrationsConfig{
+			Dir:     l.str("MIGRATIONS_DIR", "migrations"),
+			OnStart: l.bool("MIGRATE_ON_START", true),
+		},
+	}
+
+	if err := errors.Join(l.errs...); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) Validate() error {
+	var errs []error
+	required := func(name, value string) {
+		if strings.TrimSpace(value) == "" {
+			errs = append(errs, fmt.Errorf("%s is required", name))
+		}
+	}
+	positive := func(name string, value time.Duration) {
+		if value <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive", name))
+		}
+	}
+
+	required("PORT", strings.TrimPrefix(c.HTTP.Addr, ":"))
+	positive("HTTP_READ_TIMEOUT", c.HTTP.ReadTimeout)
+	positive("HTTP_WRITE_TIMEOUT", c.HTTP.WriteTimeout)
+	positive("HTTP_IDLE_TIMEOUT", c.HTTP.IdleTimeout)
+	positive("REQUEST_TIMEOUT", c.HTTP.RequestTimeout)
+	positive("HTTP_SHUTDOWN_TIMEOUT", c.HTTP.ShutdownTimeout)
+	if c.HTTP.ShutdownGrace < 0 {
+		errs = append(errs, errors.New("HTTP_SHUTDOWN_GRACE must not be negative"))
+	}
+	if c.HTTP.MaxUploadSize <= 0 {
+		errs = append(errs, errors.New("MAX_UPLOAD_SIZE must be positive"))
+	}
+	if c.HTTP.CSRFSecret != "" && len(c.HTTP.CSRFSecret) < 32 {
+		errs = append(errs, errors.New("CSRF_SECRET must be at least 32 characters long"))
+	}
+	if c.HTTP.MaxAttachments < 0 {
+		errs = append(errs, errors.New("MAX_ATTACHMENTS must not be negative"))
+	}
+	if c.HTTP.MaxAttachmentsSize <= 0 {
+		errs = append(errs, errors.New("MAX_ATTACHMENTS_SIZE must be positive"))
+	}
+
+	required("DB_HOST", c.DB.Host)
+	required("DB_USER", c.DB.User)
+	required("DB_NAME", c.DB.Name)
+	if c.DB.Port <= 0 || c.DB.Port > 65535 {
+		errs = append(errs, fmt.Errorf("DB_PORT %d is out of range", c.DB.Port))
+	}
+	positive("DB_CONNECT_TIMEOUT", c.DB.ConnectTimeout)
+	if c.DB.MaxOpenConns <= 0 {
+		errs = append(errs, errors.New("DB_MAX_OPEN_CONNS must be positive"))
+	}
+
+	switch c.Storage.Backend {
+	case StorageMinio:
+		required("MINIO_ENDPOINT", c.Minio.Endpoint)
+		required("MINIO_ACCESS_KEY", c.Minio.AccessKey)
+		required("MINIO_SECRET_KEY", c.Minio.SecretKey)
+		required("MINIO_BUCKET", c.Minio.Bucket)
+		if c.Minio.ConnectRetries <= 0 {
+			errs = append(errs, errors.New("MINIO_CONNECT_RETRIES must be positive"))
+		}
+		positive("MINIO_RETRY_DELAY", c.Minio.RetryDelay)
+	case StorageFS:
+		required("STORAGE_DIR", c.Storage.Dir)
+	default:
+		errs = append(errs, fmt.Errorf("STORAGE_BACKEND must be %q or %q, got %q", StorageMinio, StorageFS, c.Storage.Backend))
+	}
+
+	for name, value := range map[string]int64{
+		"IMAGE_MAX_WIDTH":  int64(c.Image.MaxWidth),
+		"IMAGE_MAX_HEIGHT": int64(c.Image.MaxHeight),
+		"IMAGE_MAX_PIXELS": c.Image.MaxPixels,
+		"IMAGE_MAX_FRAMES": int64(c.Image.MaxFrames),
+	} {
+		if value <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive", name))
+		}
+	}
+
+	if len(c.Image.ThumbnailSizes) == 0 {
+		errs = append(errs, errors.New("THUMBNAIL_SIZES must not be empty"))
+	}
+	for _, size := range c.Image.ThumbnailSizes {
+		if size <= 0 {
+			errs = append(errs, fmt.Errorf("THUMBNAIL_SIZES: size %d must be positive", size))
+		}
+	}
+	if c.Image.GCInterval < 0 {
+		errs = append(errs, errors.New("IMAGE_GC_INTERVAL must not be negative"))
+	}
+	positive("IMAGE_GC_GRACE", c.Image.GCGrace)
+
+	if c.Avatar.Provider != AvatarRickAndMorty && c.Avatar.Provider != AvatarIdenticon {
+		errs = append(errs, fmt.Errorf("AVATAR_PROVIDER must be %q or %q, got %q", AvatarRickAndMorty, AvatarIdenticon, c.Avatar.Provider))
+	}
+
+	positive("SESSION_TTL", c.Session.TTL)
+	if c.Session.CleanupInterval < 0 {
+		errs = append(errs, errors.New("SESSION_CLEANUP_INTERVAL must not be negative"))
+	}
+
+	if c.Username.MinLength <= 0 {
+		errs = append(errs, errors.New("USERNAME_MIN_LENGTH must be positive"))
+	}
+	if c.Username.MaxLength < c.Username.MinLength {
+		errs = append(errs, errors.New("USERNAME_MAX_LENGTH must not be less than USERNAME_MIN_LENGTH"))
+	}
+
+	if c.RateLimit.Store != RateLimitMemory && c.RateLimit.Store != RateLimitPostgres {
+		errs = append(errs, fmt.Errorf("RATE_LIMIT_STORE must be %q or %q, got %q", RateLimitMemory, RateLimitPostgres, c.RateLimit.Store))
+	}
+	positive("RATE_LIMIT_PRUNE_INTERVAL", c.RateLimit.PruneInterval)
+
+	if c.Moderation.Enabled() {
+		required("MOD_USERNAME", c.Moderation.Username)
+		if len(c.Moderation.Password) < 12 {
+			errs = append(errs, errors.New("MOD_PASSWORD must be at least 12 characters long"))
+		}
+		positive("MOD_SESSION_TTL", c.Moderation.SessionTTL)
+	}
+
+	positive("THREAD_TTL", c.Thread.TTL)
+	positive("THREAD_COMMENT_TTL", c.Thread.CommentTTL)
+	if c.Thread.MaxLifetime < 0 {
+		errs = append(errs, errors.New("THREAD_MAX_LIFETIME must not be negative"))
+	}
+	if c.Thread.BumpLimit < 0 {
+		errs = append(errs, errors.New("THREAD_BUMP_LIMIT must not be negative"))
+	}
+	positive("THREAD_SWEEP_INTERVAL", c.Thread.SweepInterval)
+	if c.Thread.ArchiveRetention < 0 {
+		errs = append(errs, errors.New("ARCHIVE_RETENTION must not be negative"))
+	}
+
+	required("LOG_DIR", c.Log.Dir)
+	required("MIGRATIONS_DIR", c.Migrations.Dir)
+
+	return errors.Join(errs...)
+}
+
+// loader читает значения из окружения с откатом на файл и значение по умолчанию,
+// накапливая ошибки разбора.
+type loader struct {
+	file map[string]string
+	errs []error
+}
+
+func (l *loader) lookup(key string) (string, bool) {
+	if v, ok := os.LookupEnv(key); ok {
+		return v, true
+	}
+	v, ok := l.file[key]
+	return v, ok
+}
+
+func (l *loader) str(key, def string) string {
+	if v, ok := l.lookup(key); ok {
+		return v
+	}
+	return def
+}
+
+func (l *loader) int(key string, def int) int {
+	v, ok := l.lookup(key)
+	if !ok {
+		return def
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s: invalid integer %q", key, v))
+		return def
+	}
+	return n
+}
+
+// strs разбирает список строк через запятую, пустые элементы пропускаются
+func (l *loader) strs(key string, def []string) []string {
+	v, ok := l.lookup(key)
+	if !ok {
+		return def
+	}
+	var values []string
+	for _, part := range strings.Split(v, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+// ints разбирает список чисел через запятую
+func (l *loader) ints(key string, def []int) []int {
+	v, ok := l.lookup(key)
+	if !ok {
+		return def
+	}
+	var values []int
+	for _, part := range strings.Split(v, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			l.errs = append(l.errs, fmt.Errorf("%s: invalid integer list %q", key, v))
+			return def
+		}
+		values = append(values, n)
+	}
+	return values
+}
+
+// rate разбирает лимит вида "10/1m"; "0" отключает лимит
+func (l *loader) rate(key string, def Rate) Rate {
+	v, ok := l.lookup(key)
+	if !ok {
+		return def
+	}
+	v = strings.TrimSpace(v)
+	if v == "0" {
+		return Rate{}
+	}
+	burst, interval, found := strings.Cut(v, "/")
+	n, err := strconv.Atoi(strings.TrimSpace(burst))
+	d, derr := time.ParseDuration(strings.TrimSpace(interval))
+	if !found || err != nil || derr != nil || n <= 0 || d <= 0 {
+		l.errs = append(l.errs, fmt.Errorf("%s: invalid rate %q, expected N/duration such as 10/1m", key, v))
+		return def
+	}
+	return Rate{Burst: n, Interval: d}
+}
+
+// networks разбирает список сетей через запятую; отдельный адрес считается сетью из одного адреса
+func (l *loader) networks(key string) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, part := range l.strs(key, nil) {
+		if !strings.Contains(part, "/") {
+			ip := net.ParseIP(part)
+			if ip == nil {
+				l.errs = append(l.errs, fmt.Errorf("%s: invalid address %q", key, part))
+				continue
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(part)
+		if err != nil {
+			l.errs = append(l.errs, fmt.Errorf("%s: invalid network %q", key, part))
+			continue
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+func (l *loader) bool(key string, def bool) bool {
+	v, ok := l.lookup(key)
+	if !ok {
+		return def
+	}
+	b, err := strconv.ParseBool(strings.TrimSpace(v))
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s: invalid boolean %q", key, v))
+		return def
+	}
+	return b
+}
+
+func (l *loader) duration(key string, def time.Duration) time.Duration {
+	v, ok := l.lookup(key)
+	if !ok {
+		return def
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(v))
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s: invalid duration %q", key, v))
+		return def
+	}
+	return d
+}
+
+// size разбирает размер в байтах, допускаются суффиксы KB, MB и GB
+func (l *loader) size(key string, def int64) int64 {
+	v, ok := l.lookup(key)
+	if !ok {
+		return def
+	}
+	n, err := parseSize(v)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s: invalid size %q", key, v))
+		return def
+	}
+	return n
+}
+
+func parseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for suffix, m := range map[string]int64{"KB": 1 << 10, "MB": 1 << 20, "GB": 1 << 30} {
+		if strings.HasSuffix(s, suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, suffix))
+			multiplier = m
+			break
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return n * multiplier, nil
+}
+
+// readEnvFile читает файл вида KEY=VALUE; пустые строки и строки с # пропускаются
+func readEnvFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, line)
+		}
+		values[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+	return values, scanner.Err()
+}
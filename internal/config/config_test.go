@@ -0,0 +1,158 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad_FileAndEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "board.env")
+	content := `
+# локальный конфиг
+DB_HOST=localhost
+DB_PORT=6543
+MINIO_BUCKET="uploads"
+MAX_UPLOAD_SIZE=5MB
+THREAD_TTL=30m
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("DB_HOST", "db.internal") // окружение важнее файла
+	t.Setenv("PORT", "9090")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	if cfg.DB.Host != "db.internal" || cfg.DB.Port != 6543 {
+		t.Errorf("db = %s:%d", cfg.DB.Host, cfg.DB.Port)
+	}
+	if cfg.HTTP.Addr != ":9090" {
+		t.Errorf("addr = %s", cfg.HTTP.Addr)
+	}
+	if cfg.Minio.Bucket != "uploads" {
+		t.Errorf("bucket = %s", cfg.Minio.Bucket)
+	}
+	if cfg.HTTP.MaxUploadSize != 5<<20 {
+		t.Errorf("max upload = %d", cfg.HTTP.MaxUploadSize)
+	}
+	if cfg.Thread.TTL != 30*time.Minute || cfg.Thread.CommentTTL != 15*time.Minute {
+		t.Errorf("thread = %+v", cfg.Thread)
+	}
+}
+
+func TestLoad_ReportsAllErrors(t *testing.T) {
+	t.Setenv("DB_PORT", "not-a-port")
+	t.Setenv("SESSION_TTL", "soon")
+
+	_, err := Load()
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	for _, key := range []string{"DB_PORT", "SESSION_TTL"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("error %q does not mention %s", err, key)
+		}
+	}
+
+	t.Setenv("DB_PORT", "5432")
+	t.Setenv("SESSION_TTL", "-1m")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "SESSION_TTL must be positive") {
+		t.Errorf("expected validation error, got %v", err)
+	}
+}
+
+func TestLoad_StorageBackend(t *testing.T) {
+	t.Setenv("STORAGE_BACKEND", "fs")
+	t.Setenv("STORAGE_DIR", "/var/lib/board/images")
+	t.Setenv("MINIO_ENDPOINT", "") // для fs настройки MinIO не нужны
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Storage.Backend != StorageFS || cfg.Storage.Dir != "/var/lib/board/images" {
+		t.Errorf("storage = %+v", cfg.Storage)
+	}
+
+	t.Setenv("STORAGE_BACKEND", "s3")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "STORAGE_BACKEND") {
+		t.Errorf("expected STORAGE_BACKEND error, got %v", err)
+	}
+}
+
+func TestLoad_AvatarProvider(t *testing.T) {
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Avatar.Provider != AvatarRickAndMorty || !cfg.Avatar.Fallback {
+		t.Errorf("default avatar config = %+v", cfg.Avatar)
+	}
+
+	t.Setenv("AVATAR_PROVIDER", "gravatar")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "AVATAR_PROVIDER") {
+		t.Errorf("expected AVATAR_PROVIDER error, got %v", err)
+	}
+}
+
+func TestLoad_RateLimit(t *testing.T) {
+	t.Setenv("RATE_LIMIT_POSTS", "2/30s")
+	t.Setenv("RATE_LIMIT_COMMENTS", "0")
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, 192.168.1.5")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.RateLimit.Posts != (Rate{Burst: 2, Interval: 30 * time.Second}) {
+		t.Errorf("posts = %+v", cfg.RateLimit.Posts)
+	}
+	if cfg.RateLimit.Comments != (Rate{}) {
+		t.Errorf("comments = %+v, want disabled", cfg.RateLimit.Comments)
+	}
+	if len(cfg.RateLimit.TrustedProxies) != 2 || cfg.RateLimit.TrustedProxies[1].String() != "192.168.1.5/32" {
+		t.Errorf("trusted proxies = %v", cfg.RateLimit.TrustedProxies)
+	}
+
+	t.Setenv("RATE_LIMIT_IMAGES", "ten per minute")
+	t.Setenv("TRUSTED_PROXIES", "proxy.local")
+	_, err = Load()
+	for _, key := range []string{"RATE_LIMIT_IMAGES", "TRUSTED_PROXIES"} {
+		if err == nil || !strings.Contains(err.Error(), key) {
+			t.Errorf("error %v does not mention %s", err, key)
+		}
+	}
+}
+
+func TestLoad_Moderation(t *testing.T) {
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Moderation.Enabled() {
+		t.Errorf("moderation is enabled without MOD_PASSWORD")
+	}
+
+	t.Setenv("MOD_USERNAME", "janitor")
+	t.Setenv("MOD_PASSWORD", "correct horse battery staple")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !cfg.Moderation.Enabled() || cfg.Moderation.Username != "janitor" || cfg.Moderation.SessionTTL != 12*time.Hour {
+		t.Errorf("moderation = %+v", cfg.Moderation)
+	}
+
+	t.Setenv("MOD_PASSWORD", "hunter2")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "MOD_PASSWORD") {
+		t.Errorf("error %v does not mention MOD_PASSWORD", err)
+	}
+}
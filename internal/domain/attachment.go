@@ -0,0 +1,12 @@
+package domain
+
+// Attachment — изображение, прикреплённое к посту или комментарию
+type Attachment struct {
+	ID           string
+	URL          string
+	ThumbnailURL string
+	Width        int
+	Height       int
+	Size         int64
+	MIMEType     string
+}
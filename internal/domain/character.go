@@ -0,0 +1,8 @@
+package domain
+
+// Character — персонаж, чьи имя и аватар получает новый пользователь
+type Character struct {
+	ID       int
+	Name     string
+	ImageURL string
+}
@@ -3,11 +3,20 @@ package domain
 import "time"
 
 type Comment struct {
-	ID         string
-	Author     string
-	Content    string
-	AvatarLink string
-	ParentID   string
-	Replies    []Comment
-	CreatedAt  time.Time
+	ID          string
+	Author      string
+	Content     string
+	AvatarLink  string
+	ParentID    string
+	Attachments []Attachment
+	Replies     []Comment
+	CreatedAt   time.Time
+}
+
+// CommentLocation — пост, которому принадлежит комментарий, и его состояние
+type CommentLocation struct {
+	CommentID    string
+	PostID       string
+	PostArchived bool
+	PostLocked   bool
 }
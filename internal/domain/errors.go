@@ -2,4 +2,15 @@ package domain
 
 import "errors"
 
-var ErrNotFound = errors.New("not found")
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrPostArchived = errors.New("post is archived")
+	// ErrPostLocked — модератор закрыл тред для новых комментариев
+	ErrPostLocked = errors.New("post is locked")
+	// ErrUnsupportedImage — загруженный файл не является изображением поддерживаемого формата
+	ErrUnsupportedImage = errors.New("unsupported image")
+	// ErrInvalidImage — изображение повреждено или превышает допустимые размеры
+	ErrInvalidImage = errors.New("invalid image")
+	// ErrInvalidUsername — имя пользователя не прошло проверку
+	ErrInvalidUsername = errors.New("invalid username")
+)
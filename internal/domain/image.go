@@ -0,0 +1,32 @@
+package domain
+
+import "strings"
+
+// imageURLPrefix — путь, по которому транспорт отдаёт объекты хранилища
+const imageURLPrefix = "/images/"
+
+// Image — проверенное изображение, сохранённое в хранилище вместе с превью
+type Image struct {
+	ObjectName  string
+	ContentType string
+	Width       int
+	Height      int
+	Size        int64
+	// Thumbnails — имена превью в порядке настроенных размеров
+	Thumbnails []string
+}
+
+// ImageURL возвращает URL, под которым объект хранилища сохраняется в постах и комментариях
+func ImageURL(objectName string) string {
+	return imageURLPrefix + objectName
+}
+
+// ImageObjectName извлекает имя объекта из URL изображения.
+// Возвращает false для внешних ссылок и пустых значений.
+func ImageObjectName(url string) (string, bool) {
+	name, ok := strings.CutPrefix(url, imageURLPrefix)
+	if !ok || name == "" || strings.Contains(name, "/") {
+		return "", false
+	}
+	return name, true
+}
@@ -0,0 +1,34 @@
+package domain
+
+import "time"
+
+// ModerationAction — вид действия модератора в журнале
+type ModerationAction string
+
+const (
+	ModDeletePost          ModerationAction = "delete_post"
+	ModDeleteComment       ModerationAction = "delete_comment"
+	ModRemovePostImages    ModerationAction = "remove_post_images"
+	ModRemoveCommentImages ModerationAction = "remove_comment_images"
+	ModLockPost            ModerationAction = "lock_post"
+	ModUnlockPost          ModerationAction = "unlock_post"
+	ModPinPost             ModerationAction = "pin_post"
+	ModUnpinPost           ModerationAction = "unpin_post"
+)
+
+const (
+	ModTargetPost    = "post"
+	ModTargetComment = "comment"
+)
+
+// ModerationLogEntry — запись журнала модерации
+type ModerationLogEntry struct {
+	ID         int64
+	Moderator  string
+	Action     ModerationAction
+	TargetType string
+	TargetID   string
+	// Details — что именно изменилось, например заголовок удалённого треда
+	Details   string
+	CreatedAt time.Time
+}
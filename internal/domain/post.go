@@ -3,20 +3,41 @@ package domain
 import "time"
 
 type Post struct {
-	ID         string
-	Title      string
-	Content    string
-	Author     string
-	ImageURL   string
-	Comments   []Comment
-	CreatedAt  time.Time
-	UserAvatar string
-	AuthorID   string
+	ID           string
+	Title        string
+	Content      string
+	Author       string
+	ImageURL     string
+	ThumbnailURL string
+	Attachments  []Attachment
+	Comments     []Comment
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+	UserAvatar   string
+	AuthorID     string
+	// Locked — тред закрыт модератором для комментариев
+	Locked bool
+	// Pinned — тред закреплён вверху каталога и не уходит в архив
+	Pinned bool
 }
 type PostSummary struct {
-	ID        string
-	Title     string
-	Author    string
-	ImageURL  string
+	ID           string
+	Title        string
+	Author       string
+	ImageURL     string
+	ThumbnailURL string
+	CreatedAt    time.Time
+	Locked       bool
+	Pinned       bool
+}
+
+// PostExpiry — состояние жизненного цикла треда для планировщика архивации
+type PostExpiry struct {
+	PostID    string
 	CreatedAt time.Time
+	ExpiresAt time.Time
+	Archived  bool
+	Comments  int
+	Locked    bool
+	Pinned    bool
 }
@@ -0,0 +1,40 @@
+package domain
+
+import "time"
+
+// RateLimit — ведро токенов: в нём помещается Burst токенов, и за Interval
+// оно наполняется от пустого до полного. Каждое действие забирает токены.
+type RateLimit struct {
+	Burst    int
+	Interval time.Duration
+}
+
+// Enabled сообщает, задан ли лимит; нулевой RateLimit ничего не ограничивает
+func (l RateLimit) Enabled() bool {
+	return l.Burst > 0 && l.Interval > 0
+}
+
+// Refill возвращает число токенов через elapsed после момента, когда их было tokens
+func (l RateLimit) Refill(tokens float64, elapsed time.Duration) float64 {
+	if elapsed > 0 {
+		tokens += elapsed.Seconds() * float64(l.Burst) / l.Interval.Seconds()
+	}
+	return min(tokens, float64(l.Burst))
+}
+
+// Wait возвращает, через сколько в ведре с tokens токенами наберётся cost токенов.
+// cost больше Burst считается равным Burst, иначе ждать пришлось бы вечно.
+func (l RateLimit) Wait(tokens float64, cost int) time.Duration {
+	missing := float64(min(cost, l.Burst)) - tokens
+	if missing <= 0 {
+		return 0
+	}
+	return time.Duration(missing * float64(l.Interval) / float64(l.Burst))
+}
+
+// RateLimitTake — сколько токенов запрос забирает из ведра Key с лимитом Limit
+type RateLimitTake struct {
+	Key   string
+	Limit RateLimit
+	Cost  int
+}
@@ -2,6 +2,7 @@ package left
 
 import (
 	"context"
+	"io"
 
 	"1337b04rd/internal/domain"
 )
@@ -10,6 +11,8 @@ type APIPort interface {
 	PostQueryPort
 	PostCommandPort
 	SessionPort
+	UserPort
+	ModerationPort
 }
 
 type PostQueryPort interface {
@@ -21,11 +24,37 @@ type PostQueryPort interface {
 
 type PostCommandPort interface {
 	AddComment(ctx context.Context, postID string, comment *domain.Comment) error
-	ReplyToComment(ctx context.Context, parentCommentID string, reply *domain.Comment) error
+	ReplyToComment(ctx context.Context, postID, parentCommentID string, reply *domain.Comment) error
 	CreatePost(ctx context.Context, post *domain.Post) error
+	// UploadImage проверяет и сохраняет изображение вместе с превью.
+	// Одинаковые изображения хранятся в одном объекте.
+	UploadImage(ctx context.Context, r io.Reader) (*domain.Image, error)
 }
 
 type SessionPort interface {
 	GetSessionByID(ctx context.Context, sessionID string) (*domain.Session, error)
 	CreateSession(ctx context.Context) (*domain.Session, error)
+	// RenewSession продлевает активную сессию на полный срок жизни, не меняя пользователя.
+	// Возвращает false, если продлевать ещё рано и срок остался прежним.
+	RenewSession(ctx context.Context, session *domain.Session) (bool, error)
+}
+
+type UserPort interface {
+	GetUser(ctx context.Context, userID string) (*domain.User, error)
+	// SetUsername проверяет и сохраняет новое имя пользователя.
+	// Некорректное имя — ошибка, оборачивающая domain.ErrInvalidUsername.
+	SetUsername(ctx context.Context, userID, name string) (*domain.User, error)
+}
+
+// ModerationPort — действия модератора. Каждое действие записывается в журнал
+// с именем модератора; если цели нет, возвращается ошибка с domain.ErrNotFound.
+type ModerationPort interface {
+	DeletePost(ctx context.Context, moderator, postID string) error
+	DeleteComment(ctx context.Context, moderator, commentID string) error
+	// RemovePostImages и RemoveCommentImages сразу удаляют изображения из хранилища
+	RemovePostImages(ctx context.Context, moderator, postID string) error
+	RemoveCommentImages(ctx context.Context, moderator, commentID string) error
+	LockPost(ctx context.Context, moderator, postID string, locked bool) error
+	PinPost(ctx context.Context, moderator, postID string, pinned bool) error
+	ModerationLog(ctx context.Context, limit int) ([]domain.ModerationLogEntry, error)
 }
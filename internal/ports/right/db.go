@@ -2,6 +2,7 @@ package right
 
 import (
 	"context"
+	"time"
 
 	"1337b04rd/internal/domain"
 )
@@ -12,6 +13,9 @@ type DbPort interface {
 	CommentRepository
 	UserRepository
 	SessionRepository
+	ImageRepository
+	CharacterRepository
+	ModerationRepository
 }
 
 type PostRepository interface {
@@ -19,25 +23,73 @@ type PostRepository interface {
 	ListCatalog(ctx context.Context) ([]*domain.PostSummary, error)
 	GetPostByID(ctx context.Context, id string) (*domain.Post, error)
 	CreatePost(ctx context.Context, post *domain.Post) error
+	GetPostExpiry(ctx context.Context, id string) (*domain.PostExpiry, error)
+	ListPostExpiries(ctx context.Context) ([]domain.PostExpiry, error)
 }
 
 type ArchiveRepository interface {
 	ListArchiveCatalog(ctx context.Context) ([]*domain.PostSummary, error)
 	GetArchivedPostByID(ctx context.Context, id string) (*domain.Post, error)
 	ArchivePostByID(ctx context.Context, id string) (*domain.Post, error)
+	ArchiveExpiredPost(ctx context.Context, id string, now time.Time) (bool, error)
+	ArchiveExpiredPosts(ctx context.Context, now time.Time) ([]string, error)
+	PurgeArchivedPosts(ctx context.Context, before time.Time) ([]string, error)
 }
 type CommentRepository interface {
-	AddComment(ctx context.Context, PostId string, comment *domain.Comment) error
+	// AddComment в одной транзакции продлевает срок треда до expiresAt и сохраняет комментарий.
+	// Возвращает domain.ErrPostArchived, если на момент now тред уже не активен.
+	AddComment(ctx context.Context, PostId string, comment *domain.Comment, now, expiresAt time.Time) error
 	ReplyToComment(ctx context.Context, PostID string, UserID string, comment *domain.Comment) error
+	GetCommentLocation(ctx context.Context, commentID string) (*domain.CommentLocation, error)
 }
 
 type UserRepository interface {
 	CreateUser(ctx context.Context, user *domain.User) error
 	GetUserByID(ctx context.Context, userID string) (*domain.User, error)
-	GetMaxCharacterID(ctx context.Context) (int, error)
+	// UpdateUsername возвращает domain.ErrNotFound, если пользователя нет
+	UpdateUsername(ctx context.Context, userID, username string) error
+	// NextCharacterSlot выдаёт уникальный порядковый номер нового пользователя, начиная с 1
+	NextCharacterSlot(ctx context.Context) (int64, error)
+}
+
+// ImageRepository сообщает, какие объекты хранилища ещё используются
+type ImageRepository interface {
+	ListImageURLs(ctx context.Context) ([]string, error)
+}
+
+// CharacterRepository — кэш персонажей, уже полученных от провайдера аватаров.
+// Если персонажа нет, методы возвращают domain.ErrNotFound.
+type CharacterRepository interface {
+	SaveCharacter(ctx context.Context, character *domain.Character) error
+	GetCharacter(ctx context.Context, id int) (*domain.Character, error)
+	RandomCharacter(ctx context.Context) (*domain.Character, error)
+	CountCharacters(ctx context.Context) (int, error)
 }
 
 type SessionRepository interface {
 	GetSession(ctx context.Context, sessionID string) (*domain.Session, error)
 	SaveSession(ctx context.Context, session *domain.Session) error
+	// TouchSession продлевает ещё активную на момент now сессию до expiresAt.
+	// Если сессии нет или она уже истекла, возвращает domain.ErrNotFound.
+	TouchSession(ctx context.Context, sessionID string, now, expiresAt time.Time) error
+	// DeleteExpiredSessions удаляет сессии, истёкшие к моменту before, и возвращает их число
+	DeleteExpiredSessions(ctx context.Context, before time.Time) (int64, error)
+}
+
+// ModerationRepository — действия модераторов и их журнал.
+// Если цели нет, методы возвращают domain.ErrNotFound.
+type ModerationRepository interface {
+	DeletePost(ctx context.Context, id string) error
+	DeleteComment(ctx context.Context, id string) error
+	// RemovePostImages и RemoveCommentImages отвязывают изображения от цели и от всех
+	// остальных постов и комментариев, где они встречаются, и возвращают URL, объекты
+	// которых нужно удалить из хранилища
+	RemovePostImages(ctx context.Context, id string) ([]string, error)
+	RemoveCommentImages(ctx context.Context, id string) ([]string, error)
+	SetPostLocked(ctx context.Context, id string, locked bool) error
+	// SetPostPinned работает только с активными тредами. При откреплении
+	// срок жизни продлевается как минимум до expiresAt.
+	SetPostPinned(ctx context.Context, id string, pinned bool, expiresAt time.Time) error
+	LogModeration(ctx context.Context, entry *domain.ModerationLogEntry) error
+	ListModerationLog(ctx context.Context, limit int) ([]domain.ModerationLogEntry, error)
 }
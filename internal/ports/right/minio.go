@@ -2,13 +2,37 @@ package right
 
 import (
 	"context"
-	"mime/multipart"
+	"io"
+	"time"
 )
 
 type MinioPort interface {
 	ImageStorage
 }
 type ImageStorage interface {
-	UploadImage(ctx context.Context, file multipart.File, fileHeader *multipart.FileHeader) (string, error)
-	GetImage(ctx context.Context, imageName string) ([]byte, string, error)
+	// UploadImage сохраняет объект под именем, выбранным приложением
+	UploadImage(ctx context.Context, objectName string, r io.Reader, size int64, contentType string) error
+	// GetImage открывает объект для потокового чтения; вызывающий обязан закрыть Body.
+	// Если объекта нет, возвращает domain.ErrNotFound.
+	GetImage(ctx context.Context, imageName string) (*ImageObject, error)
+	DeleteImage(ctx context.Context, imageName string) error
+	// ListImages перечисляет все объекты хранилища для сборки мусора
+	ListImages(ctx context.Context) ([]ObjectInfo, error)
+}
+
+// ObjectInfo — имя и метаданные объекта без его содержимого
+type ObjectInfo struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+}
+
+// ImageObject — открытый объект хранилища с метаданными для HTTP-кэширования
+type ImageObject struct {
+	Body io.ReadSeekCloser
+	Size int64
+	// ContentType может быть пустым, тогда тип определяется по содержимому
+	ContentType string
+	ModTime     time.Time
+	ETag        string
 }
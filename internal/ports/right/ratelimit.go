@@ -0,0 +1,19 @@
+package right
+
+import (
+	"context"
+	"time"
+
+	"1337b04rd/internal/domain"
+)
+
+// RateLimitStore хранит вёдра токенов. Реализация в памяти годится для одной реплики,
+// общая для нескольких реплик — в БД.
+type RateLimitStore interface {
+	// Take забирает токены сразу из всех вёдер takes. Если хотя бы в одном токенов
+	// не хватает, ничего ни из одного не забирает и возвращает, через сколько их
+	// станет достаточно во всех.
+	Take(ctx context.Context, takes []domain.RateLimitTake, now time.Time) (allowed bool, retryAfter time.Duration, err error)
+	// Prune удаляет вёдра, к которым не обращались с момента before
+	Prune(ctx context.Context, before time.Time) error
+}
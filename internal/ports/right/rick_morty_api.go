@@ -1,8 +1,14 @@
 package right
 
-import "1337b04rd/internal/domain"
+import (
+	"context"
+
+	"1337b04rd/internal/domain"
+)
 
 type AvatarProvider interface {
-	GetRandomAvatar() (*domain.User, error)
-	GetRandomAvatarByID(id int) (*domain.User, error)
+	// CharacterCount — сколько персонажей с номерами от 1 можно получить по ID
+	CharacterCount(ctx context.Context) (int, error)
+	GetRandomAvatar(ctx context.Context) (*domain.Character, error)
+	GetRandomAvatarByID(ctx context.Context, id int) (*domain.Character, error)
 }
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS Comment;
+DROP TABLE IF EXISTS Post;
+DROP TABLE IF EXISTS Session;
+DROP TABLE IF EXISTS Client;
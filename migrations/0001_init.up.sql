@@ -1,5 +1,5 @@
 -- Создание таблицы Client (переименованная User)
-CREATE TABLE Client (
+CREATE TABLE IF NOT EXISTS Client (
     user_id UUID PRIMARY KEY,
     username TEXT NOT NULL,
     image_url TEXT,
@@ -7,7 +7,7 @@ CREATE TABLE Client (
 );
 
 -- Создание таблицы Session
-CREATE TABLE Session (
+CREATE TABLE IF NOT EXISTS Session (
     session_id TEXT PRIMARY KEY,
     user_id UUID NOT NULL,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
@@ -16,7 +16,7 @@ CREATE TABLE Session (
 );
 
 -- Создание таблицы Post
-CREATE TABLE Post (
+CREATE TABLE IF NOT EXISTS Post (
     post_id UUID PRIMARY KEY,
     title TEXT NOT NULL,
     content TEXT,
@@ -28,7 +28,7 @@ CREATE TABLE Post (
 );
 
 -- Создание таблицы Comment с древовидной структурой
-CREATE TABLE Comment (
+CREATE TABLE IF NOT EXISTS Comment (
     comment_id UUID PRIMARY KEY,
     content TEXT NOT NULL,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
@@ -42,10 +42,10 @@ CREATE TABLE Comment (
 );
 
 -- Добавление индексов для оптимизации
-CREATE INDEX idx_session_user ON Session(user_id);
-CREATE INDEX idx_post_user ON Post(user_id);
-CREATE INDEX idx_comment_post ON Comment(post_id);
-CREATE INDEX idx_comment_parent ON Comment(parent_comment_id);
-CREATE INDEX idx_comment_user ON Comment(user_id);
-CREATE INDEX idx_post_created ON Post(created_at);
-CREATE INDEX idx_comment_created ON Comment(created_at);
+CREATE INDEX IF NOT EXISTS idx_session_user ON Session(user_id);
+CREATE INDEX IF NOT EXISTS idx_post_user ON Post(user_id);
+CREATE INDEX IF NOT EXISTS idx_comment_post ON Comment(post_id);
+CREATE INDEX IF NOT EXISTS idx_comment_parent ON Comment(parent_comment_id);
+CREATE INDEX IF NOT EXISTS idx_comment_user ON Comment(user_id);
+CREATE INDEX IF NOT EXISTS idx_post_created ON Post(created_at);
+CREATE INDEX IF NOT EXISTS idx_comment_created ON Comment(created_at);
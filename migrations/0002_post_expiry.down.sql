@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_post_expires_active;
+
+ALTER TABLE Post DROP COLUMN IF EXISTS expires_at;
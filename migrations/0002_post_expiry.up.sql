@@ -0,0 +1,8 @@
+-- Срок жизни треда хранится в БД, чтобы расписание архивации переживало рестарты
+ALTER TABLE Post ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+
+UPDATE Post SET expires_at = created_at + INTERVAL '10 minutes' WHERE expires_at IS NULL;
+
+ALTER TABLE Post ALTER COLUMN expires_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_post_expires_active ON Post(expires_at) WHERE is_deleted = FALSE;
@@ -0,0 +1 @@
+ALTER TABLE Post DROP COLUMN IF EXISTS thumbnail_url;
@@ -0,0 +1,2 @@
+-- Превью для каталога и архива; у старых постов остаётся NULL, и шаблоны показывают оригинал
+ALTER TABLE Post ADD COLUMN IF NOT EXISTS thumbnail_url TEXT;
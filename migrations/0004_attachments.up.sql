@@ -0,0 +1,25 @@
+-- Вложения постов и комментариев: у каждой записи ровно один владелец
+CREATE TABLE IF NOT EXISTS Attachment (
+    attachment_id UUID PRIMARY KEY,
+    post_id UUID REFERENCES Post(post_id) ON DELETE CASCADE,
+    comment_id UUID REFERENCES Comment(comment_id) ON DELETE CASCADE,
+    position INT NOT NULL DEFAULT 0,
+    url TEXT NOT NULL,
+    thumbnail_url TEXT,
+    width INT NOT NULL DEFAULT 0,
+    height INT NOT NULL DEFAULT 0,
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    mime_type TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK ((post_id IS NULL) <> (comment_id IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_attachment_post ON Attachment(post_id, position) WHERE post_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_attachment_comment ON Attachment(comment_id, position) WHERE comment_id IS NOT NULL;
+
+-- Единственное изображение старых постов становится первым вложением; размеры неизвестны
+INSERT INTO Attachment (attachment_id, post_id, position, url, thumbnail_url)
+SELECT gen_random_uuid(), p.post_id, 0, p.image_url, p.thumbnail_url
+FROM Post p
+WHERE p.image_url IS NOT NULL AND p.image_url <> ''
+  AND NOT EXISTS (SELECT 1 FROM Attachment a WHERE a.post_id = p.post_id);
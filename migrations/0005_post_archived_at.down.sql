@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_post_archived_at;
+
+ALTER TABLE Post DROP COLUMN IF EXISTS archived_at;
@@ -0,0 +1,7 @@
+-- Время архивации нужно, чтобы удалять архивные треды старше ARCHIVE_RETENTION.
+-- Для уже архивированных постов лучшее приближение — срок их жизни.
+ALTER TABLE Post ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
+
+UPDATE Post SET archived_at = LEAST(expires_at, NOW()) WHERE is_deleted = TRUE AND archived_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_post_archived_at ON Post(archived_at) WHERE is_deleted = TRUE;
@@ -0,0 +1,8 @@
+-- Кэш персонажей провайдера аватаров: позволяет выдавать аватары без доступа к API.
+-- image_url указывает на копию в хранилище изображений или, если её нет, на оригинал.
+CREATE TABLE IF NOT EXISTS Character (
+    character_id INT PRIMARY KEY,
+    name TEXT NOT NULL,
+    image_url TEXT NOT NULL,
+    cached_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1 @@
+DROP SEQUENCE IF EXISTS character_slot_seq;
@@ -0,0 +1,6 @@
+-- Номера персонажей для новых пользователей. Последовательность выдаёт уникальные
+-- значения без блокировок, в том числе между репликами.
+-- Продолжаем с числа уже созданных пользователей, чтобы не повторять выданных персонажей.
+CREATE SEQUENCE IF NOT EXISTS character_slot_seq START WITH 1;
+
+SELECT setval('character_slot_seq', COUNT(*) + 1, false) FROM Client;
@@ -0,0 +1,2 @@
+ALTER TABLE Comment DROP COLUMN IF EXISTS author_name;
+ALTER TABLE Post DROP COLUMN IF EXISTS author_name;
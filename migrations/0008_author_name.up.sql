@@ -0,0 +1,7 @@
+-- Имя автора запоминается в момент публикации: после смены имени пользователем
+-- старые посты и комментарии остаются подписаны прежним именем.
+ALTER TABLE Post ADD COLUMN IF NOT EXISTS author_name TEXT;
+ALTER TABLE Comment ADD COLUMN IF NOT EXISTS author_name TEXT;
+
+UPDATE Post p SET author_name = c.username FROM Client c WHERE p.user_id = c.user_id AND p.author_name IS NULL;
+UPDATE Comment m SET author_name = c.username FROM Client c WHERE m.user_id = c.user_id AND m.author_name IS NULL;
@@ -0,0 +1,2 @@
+-- Индекс для периодического удаления просроченных сессий
+CREATE INDEX IF NOT EXISTS idx_session_expires ON Session(expires_at);
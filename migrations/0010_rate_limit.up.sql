@@ -0,0 +1,8 @@
+-- Вёдра токенов ограничителя частоты запросов, общие для всех реплик
+CREATE TABLE IF NOT EXISTS RateLimitBucket (
+    bucket_key TEXT PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limit_bucket_updated ON RateLimitBucket(updated_at);
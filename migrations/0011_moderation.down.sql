@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS ModerationLog;
+
+ALTER TABLE Post DROP COLUMN IF EXISTS is_pinned;
+ALTER TABLE Post DROP COLUMN IF EXISTS is_locked;
@@ -0,0 +1,16 @@
+-- Закреплённые треды выводятся первыми и не уходят в архив, в закрытых нельзя комментировать
+ALTER TABLE Post ADD COLUMN IF NOT EXISTS is_locked BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE Post ADD COLUMN IF NOT EXISTS is_pinned BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Журнал действий модераторов. Цели могут быть уже удалены, поэтому внешних ключей нет.
+CREATE TABLE IF NOT EXISTS ModerationLog (
+    log_id BIGSERIAL PRIMARY KEY,
+    moderator TEXT NOT NULL,
+    action TEXT NOT NULL,
+    target_type TEXT NOT NULL,
+    target_id TEXT NOT NULL,
+    details TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_moderation_log_created ON ModerationLog(created_at);
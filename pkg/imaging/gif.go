@@ -0,0 +1,65 @@
+package imaging
+
+import "errors"
+
+var errTruncatedGIF = errors.New("truncated gif")
+
+// countGIFFrames проходит по блокам GIF без распаковки LZW и считает кадры
+func countGIFFrames(data []byte) (int, error) {
+	// Заголовок (6 байт) и Logical Screen Descriptor (7 байт)
+	if len(data) < 13 {
+		return 0, errTruncatedGIF
+	}
+	pos := 13
+	if data[10]&0x80 != 0 {
+		pos += 3 << (data[10]&0x07 + 1)
+	}
+
+	frames := 0
+	for {
+		if pos >= len(data) {
+			return 0, errTruncatedGIF
+		}
+		switch data[pos] {
+		case 0x21: // расширение: метка и подблоки
+			var err error
+			if pos, err = skipSubBlocks(data, pos+2); err != nil {
+				return 0, err
+			}
+		case 0x2C: // Image Descriptor
+			if pos+10 > len(data) {
+				return 0, errTruncatedGIF
+			}
+			flags := data[pos+9]
+			pos += 10
+			if flags&0x80 != 0 {
+				pos += 3 << (flags&0x07 + 1)
+			}
+			// Минимальный размер кода LZW, затем сжатые данные
+			var err error
+			if pos, err = skipSubBlocks(data, pos+1); err != nil {
+				return 0, err
+			}
+			frames++
+		case 0x3B: // Trailer
+			return frames, nil
+		default:
+			return 0, errors.New("unknown gif block")
+		}
+	}
+}
+
+// skipSubBlocks пропускает цепочку подблоков, завершённую блоком нулевой длины
+func skipSubBlocks(data []byte, pos int) (int, error) {
+	for {
+		if pos >= len(data) {
+			return 0, errTruncatedGIF
+		}
+		size := int(data[pos])
+		pos++
+		if size == 0 {
+			return pos, nil
+		}
+		pos += size
+	}
+}
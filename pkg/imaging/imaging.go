@@ -0,0 +1,196 @@
+// Package imaging проверяет загружаемые изображения и перекодирует их,
+// чтобы в хранилище не попадали метаданные (EXIF, GPS, комментарии) клиента.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+
+	"golang.org/x/image/webp"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	ErrTooLarge          = errors.New("image dimensions exceed limits")
+	ErrCorrupt           = errors.New("corrupt image")
+)
+
+const jpegQuality = 90
+
+// Limits ограничивает размеры изображения в пикселях. MaxPixels защищает от
+// «бомб»: маленький файл, который при декодировании занимает гигабайты памяти.
+// Для анимированного GIF MaxPixels ограничивает сумму пикселей всех кадров.
+type Limits struct {
+	MaxWidth  int
+	MaxHeight int
+	MaxPixels int64
+	// MaxFrames — максимальное число кадров анимированного GIF
+	MaxFrames int
+}
+
+func DefaultLimits() Limits {
+	return Limits{
+		MaxWidth:  8192,
+		MaxHeight: 8192,
+		MaxPixels: 40_000_000,
+		MaxFrames: 200,
+	}
+}
+
+// Image — перекодированное изображение, готовое к сохранению
+type Image struct {
+	Data        []byte
+	ContentType string
+	// Ext — расширение имени объекта, соответствующее ContentType
+	Ext    string
+	Width  int
+	Height int
+
+	// preview — декодированное изображение (для GIF — первый кадр), из которого делаются превью
+	preview image.Image
+}
+
+// Sanitize определяет формат по содержимому (а не по имени файла и заголовкам клиента),
+// проверяет размеры до полного декодирования и перекодирует изображение без метаданных.
+// JPEG, PNG и GIF сохраняют свой формат, WebP перекодируется в PNG.
+func Sanitize(r io.Reader, limits Limits) (*Image, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read image: %w", err)
+	}
+
+	switch http.DetectContentType(data) {
+	case "image/jpeg":
+		return sanitizeJPEG(data, limits)
+	case "image/png":
+		return sanitizeStill(data, limits, png.DecodeConfig, png.Decode)
+	case "image/webp":
+		return sanitizeStill(data, limits, webp.DecodeConfig, webp.Decode)
+	case "image/gif":
+		return sanitizeGIF(data, limits)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+func checkConfig(cfg image.Config, limits Limits) error {
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return fmt.Errorf("%w: empty image", ErrCorrupt)
+	}
+	if cfg.Width > limits.MaxWidth || cfg.Height > limits.MaxHeight {
+		return fmt.Errorf("%w: %dx%d, max %dx%d", ErrTooLarge, cfg.Width, cfg.Height, limits.MaxWidth, limits.MaxHeight)
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > limits.MaxPixels {
+		return fmt.Errorf("%w: %d pixels, max %d", ErrTooLarge, int64(cfg.Width)*int64(cfg.Height), limits.MaxPixels)
+	}
+	return nil
+}
+
+func decodeConfig(data []byte, limits Limits, fn func(io.Reader) (image.Config, error)) error {
+	cfg, err := fn(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	return checkConfig(cfg, limits)
+}
+
+func sanitizeJPEG(data []byte, limits Limits) (*Image, error) {
+	if err := decodeConfig(data, limits, jpeg.DecodeConfig); err != nil {
+		return nil, err
+	}
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+
+	// Ориентацию из EXIF применяем к пикселям, иначе после удаления метаданных
+	// снимки с телефона окажутся повёрнутыми
+	img = applyOrientation(img, jpegOrientation(data))
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, fmt.Errorf("encode jpeg: %w", err)
+	}
+	return newImage(buf.Bytes(), "image/jpeg", ".jpg", img.Bounds(), img), nil
+}
+
+// sanitizeStill обрабатывает форматы без анимации; результат всегда PNG
+func sanitizeStill(data []byte, limits Limits, config func(io.Reader) (image.Config, error), decode func(io.Reader) (image.Image, error)) (*Image, error) {
+	if err := decodeConfig(data, limits, config); err != nil {
+		return nil, err
+	}
+	img, err := decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("encode png: %w", err)
+	}
+	return newImage(buf.Bytes(), "image/png", ".png", img.Bounds(), img), nil
+}
+
+func sanitizeGIF(data []byte, limits Limits) (*Image, error) {
+	cfg, err := gif.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	if err := checkConfig(cfg, limits); err != nil {
+		return nil, err
+	}
+	// Кадры считаем до декодирования: каждый кадр разворачивается в полный буфер
+	frames, err := countGIFFrames(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	if frames > limits.MaxFrames {
+		return nil, fmt.Errorf("%w: %d frames, max %d", ErrTooLarge, frames, limits.MaxFrames)
+	}
+	// Кадр может занимать весь логический экран, поэтому бюджет считаем по нему
+	if total := int64(frames) * int64(cfg.Width) * int64(cfg.Height); total > limits.MaxPixels {
+		return nil, fmt.Errorf("%w: %d pixels in %d frames, max %d", ErrTooLarge, total, frames, limits.MaxPixels)
+	}
+
+	g, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		return nil, fmt.Errorf("encode gif: %w", err)
+	}
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	return newImage(buf.Bytes(), "image/gif", ".gif", bounds, firstFrame(g, bounds)), nil
+}
+
+// firstFrame рисует первый кадр GIF на холсте полного размера:
+// сам кадр может занимать только часть логического экрана
+func firstFrame(g *gif.GIF, bounds image.Rectangle) image.Image {
+	canvas := image.NewRGBA(bounds)
+	if len(g.Image) > 0 {
+		frame := g.Image[0]
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+	}
+	return canvas
+}
+
+func newImage(data []byte, contentType, ext string, bounds image.Rectangle, preview image.Image) *Image {
+	return &Image{
+		Data:        data,
+		ContentType: contentType,
+		Ext:         ext,
+		Width:       bounds.Dx(),
+		Height:      bounds.Dy(),
+		preview:     preview,
+	}
+}
@@ -0,0 +1,209 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func testImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{uint8(x * 10), uint8(y * 10), 0, 255})
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// withEXIF вставляет после SOI сегмент APP1 с тегом Orientation и «GPS»-строкой
+func withEXIF(jpg []byte, orientation uint16, secret string) []byte {
+	var tiff bytes.Buffer
+	tiff.WriteString("II*\x00")
+	binary.Write(&tiff, binary.LittleEndian, uint32(8))
+	binary.Write(&tiff, binary.LittleEndian, uint16(1))
+	binary.Write(&tiff, binary.LittleEndian, uint16(0x0112)) // Orientation
+	binary.Write(&tiff, binary.LittleEndian, uint16(3))      // SHORT
+	binary.Write(&tiff, binary.LittleEndian, uint32(1))
+	binary.Write(&tiff, binary.LittleEndian, orientation)
+	binary.Write(&tiff, binary.LittleEndian, uint16(0))
+	binary.Write(&tiff, binary.LittleEndian, uint32(0))
+	tiff.WriteString(secret)
+
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, segment...)
+	return append(out, jpg[2:]...)
+}
+
+func TestSanitize_JPEGStripsEXIFAndAppliesOrientation(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(20, 10), nil); err != nil {
+		t.Fatal(err)
+	}
+	data := withEXIF(buf.Bytes(), 6, "GPS 55.7558N 37.6173E")
+
+	img, err := Sanitize(bytes.NewReader(data), DefaultLimits())
+	if err != nil {
+		t.Fatalf("sanitize: %v", err)
+	}
+	if img.ContentType != "image/jpeg" || img.Ext != ".jpg" {
+		t.Errorf("got %s %s", img.ContentType, img.Ext)
+	}
+	if bytes.Contains(img.Data, []byte("GPS")) || bytes.Contains(img.Data, []byte("Exif")) {
+		t.Error("metadata survived re-encoding")
+	}
+	// Orientation 6 — поворот на 90°, ширина и высота меняются местами
+	if img.Width != 10 || img.Height != 20 {
+		t.Errorf("size = %dx%d, want 10x20", img.Width, img.Height)
+	}
+}
+
+func TestSanitize_PNGAndGIF(t *testing.T) {
+	pngData := encodePNG(t, testImage(4, 4))
+	img, err := Sanitize(bytes.NewReader(pngData), DefaultLimits())
+	if err != nil || img.ContentType != "image/png" {
+		t.Fatalf("png: %+v, %v", img, err)
+	}
+
+	anim := &gif.GIF{}
+	for i := 0; i < 3; i++ {
+		frame := image.NewPaletted(image.Rect(0, 0, 4, 4), palette.Plan9)
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, 10)
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		t.Fatal(err)
+	}
+	if frames, err := countGIFFrames(buf.Bytes()); err != nil || frames != 3 {
+		t.Fatalf("countGIFFrames = %d, %v", frames, err)
+	}
+
+	img, err = Sanitize(bytes.NewReader(buf.Bytes()), DefaultLimits())
+	if err != nil || img.ContentType != "image/gif" {
+		t.Fatalf("gif: %+v, %v", img, err)
+	}
+
+	limits := DefaultLimits()
+	limits.MaxFrames = 2
+	if _, err := Sanitize(bytes.NewReader(buf.Bytes()), limits); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("too many frames: err = %v, want ErrTooLarge", err)
+	}
+}
+
+func TestSanitize_GIFTotalPixels(t *testing.T) {
+	// Маленький файл: кадры по одному пикселю на холсте 2000x2000
+	anim := &gif.GIF{Config: image.Config{Width: 2000, Height: 2000, ColorModel: color.Palette(palette.Plan9)}}
+	for i := 0; i < 11; i++ {
+		anim.Image = append(anim.Image, image.NewPaletted(image.Rect(0, 0, 1, 1), palette.Plan9))
+		anim.Delay = append(anim.Delay, 10)
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		t.Fatal(err)
+	}
+
+	// Каждый кадр укладывается в MaxPixels, а все вместе — нет
+	if _, err := Sanitize(bytes.NewReader(buf.Bytes()), DefaultLimits()); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("err = %v, want ErrTooLarge", err)
+	}
+
+	limits := DefaultLimits()
+	limits.MaxPixels = 11 * 2000 * 2000
+	if _, err := Sanitize(bytes.NewReader(buf.Bytes()), limits); err != nil {
+		t.Errorf("within budget: %v", err)
+	}
+}
+
+func TestSanitize_Rejects(t *testing.T) {
+	pngData := encodePNG(t, testImage(20, 20))
+
+	small := DefaultLimits()
+	small.MaxPixels = 100
+
+	tests := []struct {
+		name   string
+		data   []byte
+		limits Limits
+		want   error
+	}{
+		{"text", []byte("<html>not an image</html>"), DefaultLimits(), ErrUnsupportedFormat},
+		{"svg", []byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`), DefaultLimits(), ErrUnsupportedFormat},
+		{"truncated", pngData[:len(pngData)/2], DefaultLimits(), ErrCorrupt},
+		{"too many pixels", pngData, small, ErrTooLarge},
+		{"too wide", pngData, Limits{MaxWidth: 10, MaxHeight: 100, MaxPixels: 1 << 20, MaxFrames: 1}, ErrTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Sanitize(bytes.NewReader(tt.data), tt.limits); !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	img, err := Sanitize(bytes.NewReader(encodePNG(t, testImage(400, 200))), DefaultLimits())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	thumb, err := img.Thumbnail(100)
+	if err != nil {
+		t.Fatalf("thumbnail: %v", err)
+	}
+	if thumb.ContentType != "image/jpeg" || thumb.Width != 100 || thumb.Height != 50 {
+		t.Errorf("thumbnail = %s %dx%d, want image/jpeg 100x50", thumb.ContentType, thumb.Width, thumb.Height)
+	}
+
+	// Маленькие изображения не увеличиваются
+	thumb, err = img.Thumbnail(1000)
+	if err != nil || thumb.Width != 400 || thumb.Height != 200 {
+		t.Errorf("large thumbnail = %+v, %v", thumb, err)
+	}
+}
+
+func TestThumbnail_AnimatedGIFIsStatic(t *testing.T) {
+	anim := &gif.GIF{}
+	for i := 0; i < 2; i++ {
+		anim.Image = append(anim.Image, image.NewPaletted(image.Rect(0, 0, 50, 50), palette.Plan9))
+		anim.Delay = append(anim.Delay, 10)
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := Sanitize(&buf, DefaultLimits())
+	if err != nil {
+		t.Fatal(err)
+	}
+	thumb, err := img.Thumbnail(20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(thumb.Data)); err != nil {
+		t.Errorf("thumbnail is not a JPEG: %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+
+	"golang.org/x/image/draw"
+)
+
+const thumbnailQuality = 80
+
+// Thumbnail уменьшает изображение так, чтобы оно помещалось в квадрат size×size,
+// и кодирует результат в JPEG. Маленькие изображения не увеличиваются.
+// Для анимированных GIF берётся первый кадр.
+func (img *Image) Thumbnail(size int) (*Image, error) {
+	if img.preview == nil {
+		return nil, fmt.Errorf("image has no decoded preview")
+	}
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid thumbnail size %d", size)
+	}
+
+	src := img.preview.Bounds()
+	w, h := fit(src.Dx(), src.Dy(), size)
+
+	// JPEG не поддерживает прозрачность, поэтому подкладываем белый фон
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img.preview, src, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil, fmt.Errorf("encode thumbnail: %w", err)
+	}
+	return newImage(buf.Bytes(), "image/jpeg", ".jpg", dst.Bounds(), dst), nil
+}
+
+// fit возвращает размеры, вписанные в квадрат size×size с сохранением пропорций
+func fit(w, h, size int) (int, int) {
+	if w <= size && h <= size {
+		return w, h
+	}
+	if w >= h {
+		return size, max(1, h*size/w)
+	}
+	return max(1, w*size/h), size
+}